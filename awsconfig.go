@@ -0,0 +1,93 @@
+package paramstore
+
+import (
+	"context"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+)
+
+var (
+	// defaultConfigMu guards defaultConfig
+	defaultConfigMu sync.Mutex
+	// defaultConfig is the shared result of LoadDefaultConfig used by
+	// providers with CacheDefaultConfig
+	defaultConfig *aws.Config
+)
+
+// ResetDefaultConfigCache drops the AWS config cached for CacheDefaultConfig,
+// so the next provider loads it again, e.g. after the environment or shared
+// config files changed. Existing providers keep their clients.
+func ResetDefaultConfigCache() {
+	defaultConfigMu.Lock()
+	defer defaultConfigMu.Unlock()
+
+	defaultConfig = nil
+}
+
+// loadConfig loads the AWS config for cfg, including its retry settings.
+func loadConfig(cfg *Config) (aws.Config, error) {
+	retryer := configRetryer(cfg)
+
+	// ConfigOptions may change anything, so they can't share a cached config
+	if !cfg.CacheDefaultConfig || len(cfg.ConfigOptions) > 0 {
+		var opts []func(*config.LoadOptions) error
+
+		// Set the retry mode
+		if cfg.RetryMode != "" {
+			opts = append(opts, config.WithRetryMode(cfg.RetryMode))
+		}
+
+		if retryer != nil {
+			opts = append(opts, config.WithRetryer(retryer))
+		}
+
+		// Refresh default chain credentials early, like injected ones
+		opts = append(opts, config.WithCredentialsCacheOptions(refreshEarly))
+
+		// Apply user supplied load options last
+		opts = append(opts, cfg.ConfigOptions...)
+
+		return config.LoadDefaultConfig(context.Background(), opts...)
+	}
+
+	defaultConfigMu.Lock()
+	defer defaultConfigMu.Unlock()
+
+	// Failed loads aren't cached, so they are retried by the next provider
+	if defaultConfig == nil {
+		c, err := config.LoadDefaultConfig(context.Background(), config.WithCredentialsCacheOptions(refreshEarly))
+
+		if err != nil {
+			return aws.Config{}, err
+		}
+
+		defaultConfig = &c
+	}
+
+	c := defaultConfig.Copy()
+
+	if cfg.RetryMode != "" {
+		c.RetryMode = cfg.RetryMode
+	}
+
+	if retryer != nil {
+		c.Retryer = retryer
+	}
+
+	return c, nil
+}
+
+// configRetryer returns the retryer constructor for cfg, or nil if the SDK
+// defaults should be used.
+func configRetryer(cfg *Config) func() aws.Retryer {
+	// Share the caller's retryer as is
+	if cfg.Retryer != nil {
+		return func() aws.Retryer {
+			return cfg.Retryer
+		}
+	}
+
+	return newRetryer(*cfg)
+}
@@ -0,0 +1,50 @@
+package paramstore
+
+import (
+	"context"
+	"errors"
+
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+)
+
+// ErrReadOnly is returned by writes to a backend wrapped with ReadOnly.
+var ErrReadOnly = errors.New("backend is read-only")
+
+// BackendReader lists and fetches parameters. Read, Watch and all other
+// read accessors only need these operations.
+type BackendReader interface {
+	GetParametersByPath(ctx context.Context, params *ssm.GetParametersByPathInput, optFns ...func(*ssm.Options)) (*ssm.GetParametersByPathOutput, error)
+	GetParameters(ctx context.Context, params *ssm.GetParametersInput, optFns ...func(*ssm.Options)) (*ssm.GetParametersOutput, error)
+	GetParameter(ctx context.Context, params *ssm.GetParameterInput, optFns ...func(*ssm.Options)) (*ssm.GetParameterOutput, error)
+	GetParameterHistory(ctx context.Context, params *ssm.GetParameterHistoryInput, optFns ...func(*ssm.Options)) (*ssm.GetParameterHistoryOutput, error)
+	DescribeParameters(ctx context.Context, params *ssm.DescribeParametersInput, optFns ...func(*ssm.Options)) (*ssm.DescribeParametersOutput, error)
+}
+
+// BackendWriter stores parameters, as needed by WriteParameter and Import.
+type BackendWriter interface {
+	PutParameter(ctx context.Context, params *ssm.PutParameterInput, optFns ...func(*ssm.Options)) (*ssm.PutParameterOutput, error)
+}
+
+// Backend is the parameter store the provider talks to, expressed in terms
+// of the SSM API. Provider uses *ssm.Client, which satisfies it; any other
+// implementation, such as an in-memory store for tests or an adapter for an
+// SSM-compatible service, can be passed to ProviderWithClient. Diffing,
+// transformation and unflattening only depend on this interface.
+type Backend interface {
+	BackendReader
+	BackendWriter
+}
+
+// ReadOnly turns r into a Backend whose writes fail with ErrReadOnly, for
+// stores that can't be written to.
+func ReadOnly(r BackendReader) Backend {
+	return readOnly{r}
+}
+
+type readOnly struct {
+	BackendReader
+}
+
+func (readOnly) PutParameter(context.Context, *ssm.PutParameterInput, ...func(*ssm.Options)) (*ssm.PutParameterOutput, error) {
+	return nil, ErrReadOnly
+}
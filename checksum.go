@@ -0,0 +1,37 @@
+package paramstore
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+)
+
+// Checksum returns a SHA-256 hash over the transformed keys and values of the
+// last Read. Keys are sorted before hashing, so equal configs always produce
+// the same checksum.
+func (ps *ParamStore) Checksum() (string, error) {
+	ps.mu.RLock()
+	flat := ps.flat
+	ps.mu.RUnlock()
+
+	if flat == nil {
+		return "", errors.New("no parameters have been read yet")
+	}
+
+	return checksum(flat)
+}
+
+func checksum(flat map[string]interface{}) (string, error) {
+	// Hash the real secret values, not their redacted form. encoding/json
+	// writes map keys in sorted order.
+	b, err := json.Marshal(revealSecrets(flat))
+
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(b)
+
+	return hex.EncodeToString(sum[:]), nil
+}
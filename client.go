@@ -0,0 +1,405 @@
+package paramstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"golang.org/x/time/rate"
+)
+
+// CredentialPrecedence decides which credential source is used when both
+// static keys and a role ARN are configured.
+type CredentialPrecedence string
+
+const (
+	// CredentialPrecedenceRole assumes AWSRoleARN, using the static keys (if
+	// any) as the source credentials for the AssumeRole call. This is the
+	// default.
+	CredentialPrecedenceRole CredentialPrecedence = "role"
+	// CredentialPrecedenceStatic uses the static keys directly and ignores
+	// AWSRoleARN.
+	CredentialPrecedenceStatic CredentialPrecedence = "static"
+)
+
+// validate checks that p is a known precedence or empty.
+func (p CredentialPrecedence) validate() error {
+	switch p {
+	case "", CredentialPrecedenceRole, CredentialPrecedenceStatic:
+		return nil
+	default:
+		return fmt.Errorf("unknown credential precedence %q", p)
+	}
+}
+
+// SSMClient is the name ProviderWithClient has always used for Backend.
+type SSMClient = Backend
+
+// Logger is the minimal logging interface used by the provider.
+// *log.Logger satisfies it.
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+func logf(l Logger, format string, v ...interface{}) {
+	if l != nil {
+		l.Printf(format, v...)
+	}
+}
+
+// ensureClient builds the client of a lazy provider on first use. A failed
+// build isn't remembered, so the next call tries again.
+func (ps *ParamStore) ensureClient() error {
+	ps.mu.RLock()
+	initialized := ps.client != nil
+	ps.mu.RUnlock()
+
+	if initialized {
+		return nil
+	}
+
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	// Another call may have built it in the meantime
+	if ps.client != nil {
+		return nil
+	}
+
+	cfg := ps.config
+	client, awsCfg, err := newClient(&cfg)
+
+	if err != nil {
+		return err
+	}
+
+	ps.client = client
+	ps.awsConfig = awsCfg
+
+	return nil
+}
+
+// snapshot returns a consistent view of the config and client, initializing
+// the client first if needed.
+func (ps *ParamStore) snapshot() (Config, SSMClient, error) {
+	if err := ps.ensureClient(); err != nil {
+		return Config{}, nil, err
+	}
+
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	return ps.config, ps.client, nil
+}
+
+// credentialsExpiryWindow makes cached credentials refresh this long before
+// they actually expire, so a request never goes out with credentials that
+// expire in flight.
+const credentialsExpiryWindow = time.Minute
+
+// refreshEarly sets the expiry window of a credentials cache.
+func refreshEarly(o *aws.CredentialsCacheOptions) {
+	o.ExpiryWindow = credentialsExpiryWindow
+}
+
+// cacheCredentials wraps p in a credentials cache unless it already is one.
+// Caches of the default chain get the expiry window when the config is
+// loaded.
+func cacheCredentials(p aws.CredentialsProvider) aws.CredentialsProvider {
+	if p == nil {
+		return nil
+	}
+
+	if _, ok := p.(*aws.CredentialsCache); ok {
+		return p
+	}
+
+	return aws.NewCredentialsCache(p, refreshEarly)
+}
+
+// CredentialsExpiry returns when the credentials the client currently uses
+// expire, retrieving them first if needed. Since they are cached, this is the
+// time they get refreshed, up to a minute before the actual expiry. It fails
+// for credentials that don't expire, like static keys, and for clients
+// injected via ProviderWithClient.
+func (ps *ParamStore) CredentialsExpiry(ctx context.Context) (time.Time, error) {
+	if _, _, err := ps.snapshot(); err != nil {
+		return time.Time{}, err
+	}
+
+	ps.mu.RLock()
+	provider := ps.awsConfig.Credentials
+	ps.mu.RUnlock()
+
+	if provider == nil {
+		return time.Time{}, errors.New("no credentials provider known for this client")
+	}
+
+	creds, err := provider.Retrieve(ctx)
+
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if !creds.CanExpire {
+		return time.Time{}, fmt.Errorf("credentials from %s don't expire", creds.Source)
+	}
+
+	return creds.Expires, nil
+}
+
+// roleOptions applies cfg to the options of the assume role provider.
+func roleOptions(cfg Config) func(*stscreds.AssumeRoleOptions) {
+	return func(o *stscreds.AssumeRoleOptions) {
+		if cfg.AWSRoleDuration > 0 {
+			o.Duration = cfg.AWSRoleDuration
+		}
+	}
+}
+
+// minRoleDuration and maxRoleDuration bound the session duration STS accepts.
+const (
+	minRoleDuration = 15 * time.Minute
+	maxRoleDuration = 12 * time.Hour
+)
+
+// credentialsCheckTimeout bounds the credential retrieval of
+// RequireCredentials, which may involve IMDS or STS calls.
+const credentialsCheckTimeout = 10 * time.Second
+
+// checkCredentials fails if p yields no usable credentials.
+func checkCredentials(p aws.CredentialsProvider) error {
+	if p == nil {
+		return errors.New("no AWS credentials configured: set static keys, a role, a shared config profile or run with an instance or task role")
+	}
+
+	// AnonymousCredentials fails to retrieve by design
+	if aws.IsCredentialsProvider(p, aws.AnonymousCredentials{}) {
+		return errors.New("resolved AWS credentials are anonymous, SSM requires signed requests")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), credentialsCheckTimeout)
+	defer cancel()
+
+	creds, err := p.Retrieve(ctx)
+
+	if err != nil {
+		return fmt.Errorf("no AWS credentials found: set static keys, a role, a shared config profile or run with an instance or task role: %w", err)
+	}
+
+	if !creds.HasKeys() {
+		return errors.New("resolved AWS credentials are empty")
+	}
+
+	return nil
+}
+
+// applyDefaults fills in unset config values.
+func applyDefaults(cfg *Config) {
+	// Initialize delimiter string
+	if cfg.Delimiter == "" {
+		cfg.Delimiter = "/"
+	}
+
+	// Initialize watch interval
+	if cfg.WatchInterval == 0 {
+		cfg.WatchInterval = 600 * time.Second
+	}
+
+	// Build a private limiter for a plain rate
+	if cfg.RateLimit > 0 && cfg.RateLimiter == nil {
+		cfg.RateLimiter = rate.NewLimiter(cfg.RateLimit, 1)
+	}
+}
+
+// validate checks that cfg can be used for reading.
+func validate(cfg Config) error {
+	// Check if path or tags are provided
+	if cfg.Path == "" && len(cfg.DiscoverTags) == 0 && len(cfg.Paths) == 0 {
+		return errors.New("no parameter path provided")
+	}
+
+	for _, spec := range cfg.Paths {
+		if spec.Path == "" {
+			return errors.New("empty path in Paths")
+		}
+
+		if err := validateFilters(spec.ParameterFilters); err != nil {
+			return err
+		}
+	}
+
+	switch cfg.DelimiterConflict {
+	case DelimiterConflictIgnore, DelimiterConflictError, DelimiterConflictReplace:
+	default:
+		return fmt.Errorf("unknown delimiter conflict mode %q", cfg.DelimiterConflict)
+	}
+
+	switch cfg.RetryMode {
+	case "", aws.RetryModeStandard, aws.RetryModeAdaptive:
+	default:
+		return fmt.Errorf("unknown retry mode %q", cfg.RetryMode)
+	}
+
+	switch cfg.RetryJitter {
+	case RetryJitterFull, RetryJitterNone:
+	default:
+		return fmt.Errorf("unknown retry jitter %q", cfg.RetryJitter)
+	}
+
+	switch cfg.SortBy {
+	case SortByName, SortByLastModified:
+	default:
+		return fmt.Errorf("unknown sort order %q", cfg.SortBy)
+	}
+
+	if cfg.NestUnderPath && cfg.StripSegments > 0 {
+		return errors.New("NestUnderPath and StripSegments are mutually exclusive")
+	}
+
+	if err := validateFilters(cfg.ParameterFilters); err != nil {
+		return err
+	}
+
+	if cfg.AWSRoleDuration != 0 && (cfg.AWSRoleDuration < minRoleDuration || cfg.AWSRoleDuration > maxRoleDuration) {
+		return fmt.Errorf("role duration %s out of range [%s, %s]", cfg.AWSRoleDuration, minRoleDuration, maxRoleDuration)
+	}
+
+	if cfg.RetryMaxDelay < 0 {
+		return errors.New("retry max delay must not be negative")
+	}
+
+	switch cfg.KeyCase {
+	case KeyCaseNone, KeyCaseLower, KeyCaseUpper:
+	default:
+		return fmt.Errorf("unknown key case %q", cfg.KeyCase)
+	}
+
+	return cfg.CredentialPrecedence.validate()
+}
+
+// clientConfigChanged reports whether the SSM client has to be rebuilt when
+// switching from old to cfg.
+func clientConfigChanged(old, cfg Config) bool {
+	return old.AWSAccessKeyID != cfg.AWSAccessKeyID ||
+		old.AWSSecretAccessKey != cfg.AWSSecretAccessKey ||
+		old.AWSRoleARN != cfg.AWSRoleARN ||
+		old.AWSRegion != cfg.AWSRegion ||
+		old.CredentialPrecedence != cfg.CredentialPrecedence ||
+		old.RetryMode != cfg.RetryMode ||
+		old.RetryJitter != cfg.RetryJitter ||
+		old.RetryMaxDelay != cfg.RetryMaxDelay ||
+		old.AWSRoleDuration != cfg.AWSRoleDuration ||
+		old.UserAgentSuffix != cfg.UserAgentSuffix ||
+		!sameProvider(old.Credentials, cfg.Credentials) ||
+		old.RequireCredentials != cfg.RequireCredentials ||
+		old.SSMEndpointURL != cfg.SSMEndpointURL ||
+		old.STSEndpointURL != cfg.STSEndpointURL
+}
+
+// sameProvider reports whether a and b are known to be the same credentials
+// provider. Providers that can't be compared, like funcs, never are.
+func sameProvider(a, b aws.CredentialsProvider) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+
+	if !reflect.TypeOf(a).Comparable() || !reflect.TypeOf(b).Comparable() {
+		return false
+	}
+
+	return a == b
+}
+
+// newClient applies defaults to cfg and builds an SSM client from the default
+// AWS config chain plus any credentials configured in cfg. It also returns the
+// AWS config the client was built from.
+func newClient(cfg *Config) (*ssm.Client, aws.Config, error) {
+	// Don't guess the credential source from a typo
+	if err := cfg.CredentialPrecedence.validate(); err != nil {
+		return nil, aws.Config{}, err
+	}
+
+	// Load the default config
+	c, err := loadConfig(cfg)
+
+	if err != nil {
+		return nil, aws.Config{}, err
+	}
+
+	applyDefaults(cfg)
+
+	// Use the injected credentials instead of the default chain
+	if cfg.Credentials != nil {
+		c.Credentials = cfg.Credentials
+	}
+
+	// Resolve the region dynamically if requested
+	if cfg.RegionResolver != nil {
+		region, err := cfg.RegionResolver()
+
+		if err != nil {
+			return nil, aws.Config{}, fmt.Errorf("resolving region: %w", err)
+		}
+
+		cfg.AWSRegion = region
+	}
+
+	// Initialize AWS region
+	if cfg.AWSRegion != "" {
+		c.Region = cfg.AWSRegion
+	}
+
+	hasStatic := cfg.AWSAccessKeyID != "" && cfg.AWSSecretAccessKey != ""
+	hasRole := cfg.AWSRoleARN != ""
+
+	// Warn about ambiguous credential config
+	if hasStatic && hasRole && cfg.CredentialPrecedence == "" {
+		logf(cfg.Logger, "paramstore: both static keys and a role ARN are configured; assuming role %s with the static keys as source credentials (set CredentialPrecedence to silence this warning)", cfg.AWSRoleARN)
+	}
+
+	// Check if AWS access key ID and secret key are specified
+	if hasStatic {
+		c.Credentials = credentials.NewStaticCredentialsProvider(cfg.AWSAccessKeyID, cfg.AWSSecretAccessKey, "")
+	}
+
+	// Check if AWS role ARN is present and should be used
+	if hasRole && !(hasStatic && cfg.CredentialPrecedence == CredentialPrecedenceStatic) {
+		stsSvc := sts.NewFromConfig(c, func(o *sts.Options) {
+			// Use a custom STS endpoint if configured
+			if cfg.STSEndpointURL != "" {
+				o.BaseEndpoint = aws.String(cfg.STSEndpointURL)
+			}
+		})
+		credentials := stscreds.NewAssumeRoleProvider(stsSvc, cfg.AWSRoleARN, roleOptions(*cfg))
+		c.Credentials = credentials
+	}
+
+	// Cache credentials so expiring ones are refreshed transparently,
+	// letting long-running watches recover from expiry on their own
+	c.Credentials = cacheCredentials(c.Credentials)
+
+	// Fail early on a credential chain that comes up empty
+	if cfg.RequireCredentials {
+		if err := checkCredentials(c.Credentials); err != nil {
+			return nil, aws.Config{}, err
+		}
+	}
+
+	return ssm.NewFromConfig(c, func(o *ssm.Options) {
+		// Identify the provider in the user agent
+		o.APIOptions = append(o.APIOptions, userAgentOptions(*cfg)...)
+
+		// Use a custom SSM endpoint if configured
+		if cfg.SSMEndpointURL != "" {
+			o.BaseEndpoint = aws.String(cfg.SSMEndpointURL)
+		}
+	}), c, nil
+}
@@ -0,0 +1,601 @@
+package paramstore
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+func TestCustomEndpointIsSignedForSSM(t *testing.T) {
+	srv := newSSMServer(t, newFakeSSM("/app/a", "1"))
+
+	cfg := srv.config("/app")
+	cfg.AWSRegion = "eu-west-1"
+
+	ps := Provider(cfg, nil)
+
+	if ps == nil {
+		t.Fatal("Provider failed")
+	}
+
+	if _, err := ps.Read(); err != nil {
+		t.Fatal(err)
+	}
+
+	req := srv.last()
+	date := req.Header.Get("X-Amz-Date")
+	auth := req.Header.Get("Authorization")
+
+	if scope := "Credential=AKID/" + date[:8] + "/eu-west-1/ssm/aws4_request"; !strings.Contains(auth, scope) {
+		t.Fatalf("got %q, want scope %s", auth, scope)
+	}
+
+	// Sign the same request again and compare the signatures
+	signingTime, err := time.Parse("20060102T150405Z", date)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	check, err := http.NewRequest(req.Method, srv.URL+req.URL.RequestURI(), bytes.NewReader(req.body))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Accept-Encoding is added by the transport after signing
+	check.Header = req.Header.Clone()
+	check.Header.Del("Authorization")
+	check.Header.Del("Accept-Encoding")
+
+	sum := sha256.Sum256(req.body)
+	creds := aws.Credentials{AccessKeyID: "AKID", SecretAccessKey: "SECRET"}
+
+	if err := v4.NewSigner().SignHTTP(context.Background(), creds, check, hex.EncodeToString(sum[:]), "ssm", "eu-west-1", signingTime); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := check.Header.Get("Authorization"); got != auth {
+		t.Fatalf("got signature %q, want %q", auth, got)
+	}
+}
+
+func TestUpdateConfigIsSerialized(t *testing.T) {
+	srv := newSSMServer(t, newFakeSSM("/app/a", "1"))
+	ps := Provider(srv.config("/app"), nil)
+
+	if ps == nil {
+		t.Fatal("Provider failed")
+	}
+
+	// Hold the first update while its client is being built
+	building := make(chan struct{})
+	release := make(chan struct{})
+
+	slow := srv.config("/app")
+	slow.AWSRegion = "eu-west-1"
+	slow.RegionResolver = func() (string, error) {
+		close(building)
+		<-release
+
+		return "eu-west-1", nil
+	}
+
+	first := make(chan error)
+
+	go func() {
+		first <- ps.UpdateConfig(slow)
+	}()
+
+	<-building
+
+	// Switch back to the original region, which needs no new client
+	second := make(chan error)
+
+	go func() {
+		second <- ps.UpdateConfig(srv.config("/app"))
+	}()
+
+	select {
+	case <-second:
+		t.Fatal("second update finished while the first was still building its client")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	close(release)
+
+	if err := <-first; err != nil {
+		t.Fatal(err)
+	}
+
+	if err := <-second; err != nil {
+		t.Fatal(err)
+	}
+
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	if ps.config.AWSRegion != ps.awsConfig.Region {
+		t.Fatalf("config for %s paired with a client for %s", ps.config.AWSRegion, ps.awsConfig.Region)
+	}
+}
+
+func TestLazyProviderRetriesFailedBuild(t *testing.T) {
+	srv := newSSMServer(t, newFakeSSM("/app/a", "1"))
+
+	cfg := srv.config("/app")
+	cfg.Lazy = true
+
+	// Fail the first build only
+	calls := 0
+	cfg.RegionResolver = func() (string, error) {
+		calls++
+
+		if calls == 1 {
+			return "", errors.New("metadata service unavailable")
+		}
+
+		return "us-east-1", nil
+	}
+
+	ps := Provider(cfg, nil)
+
+	if ps == nil {
+		t.Fatal("Provider failed")
+	}
+
+	if _, err := ps.Read(); err == nil || !strings.Contains(err.Error(), "metadata service unavailable") {
+		t.Fatalf("got %v, want the resolver error", err)
+	}
+
+	mp, err := ps.Read()
+
+	if err != nil {
+		t.Fatalf("didn't recover after the resolver succeeded: %v", err)
+	}
+
+	if mp["app"].(map[string]interface{})["a"] != "1" {
+		t.Fatalf("got %v", mp)
+	}
+}
+
+// logRecorder is a Logger collecting the formatted lines.
+type logRecorder struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (l *logRecorder) Printf(format string, v ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.lines = append(l.lines, fmt.Sprintf(format, v...))
+}
+
+func (l *logRecorder) contains(s string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, line := range l.lines {
+		if strings.Contains(line, s) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// rotatingCredentials hands out short-lived OLD credentials first and NEW
+// ones on every later retrieval.
+type rotatingCredentials struct {
+	mu        sync.Mutex
+	retrieved int
+	expires   time.Time
+}
+
+func (c *rotatingCredentials) Retrieve(context.Context) (aws.Credentials, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.retrieved++
+
+	if c.retrieved == 1 {
+		return aws.Credentials{AccessKeyID: "OLD", SecretAccessKey: "SECRET", CanExpire: true, Expires: c.expires}, nil
+	}
+
+	return aws.Credentials{AccessKeyID: "NEW", SecretAccessKey: "SECRET", CanExpire: true, Expires: time.Now().Add(time.Hour)}, nil
+}
+
+func TestWatchRecoversFromExpiredCredentials(t *testing.T) {
+	fake := newFakeSSM("/app/a", "1")
+	srv := newSSMServer(t, fake)
+
+	// OLD stops working 300ms from now, which the cache must anticipate by
+	// refreshing it 100ms earlier
+	expired := time.Now().Add(300 * time.Millisecond)
+
+	srv.hook = func(w http.ResponseWriter, r *http.Request) bool {
+		if !strings.Contains(r.Header.Get("Authorization"), "Credential=OLD/") || time.Now().Before(expired) {
+			return false
+		}
+
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"__type":"ExpiredTokenException","message":"The security token included in the request is expired"}`))
+
+		return true
+	}
+
+	cfg := srv.config("/app")
+	cfg.AWSAccessKeyID, cfg.AWSSecretAccessKey = "", ""
+	creds := &rotatingCredentials{expires: expired.Add(credentialsExpiryWindow - 100*time.Millisecond)}
+	cfg.Credentials = creds
+	cfg.WatchInterval = 5 * time.Millisecond
+
+	ps := Provider(cfg, nil)
+
+	if ps == nil {
+		t.Fatal("Provider failed")
+	}
+
+	if _, err := ps.Read(); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	rec := &watchRecorder{}
+
+	if err := ps.WatchContext(ctx, rec.cb); err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(310 * time.Millisecond)
+	fake.set("/app/a", "2", types.ParameterTypeString)
+
+	waitFor(t, func() bool {
+		return reportedNames(rec.eventList())["/app/a"]
+	})
+
+	if errs := rec.errors(); len(errs) > 0 {
+		t.Fatalf("got errors %v, want the credentials refreshed before expiry", errs)
+	}
+
+	if auth := srv.last().Header.Get("Authorization"); !strings.Contains(auth, "Credential=NEW/") {
+		t.Fatalf("last request signed with %q, want the refreshed credentials", auth)
+	}
+
+	// Both credentials must have been cached rather than retrieved per call
+	creds.mu.Lock()
+	defer creds.mu.Unlock()
+
+	if creds.retrieved != 2 {
+		t.Fatalf("retrieved credentials %d times, want 2", creds.retrieved)
+	}
+}
+
+func TestCredentialPrecedence(t *testing.T) {
+	static := (*credentials.StaticCredentialsProvider)(nil)
+	role := (*stscreds.AssumeRoleProvider)(nil)
+
+	for _, tc := range []struct {
+		name       string
+		keys, arn  bool
+		precedence CredentialPrecedence
+		want       aws.CredentialsProvider
+		warns      bool
+	}{
+		{name: "keys", keys: true, want: static},
+		{name: "role", arn: true, want: role},
+		{name: "both", keys: true, arn: true, want: role, warns: true},
+		{name: "both with role precedence", keys: true, arn: true, precedence: CredentialPrecedenceRole, want: role},
+		{name: "both with static precedence", keys: true, arn: true, precedence: CredentialPrecedenceStatic, want: static},
+		{name: "role with static precedence", arn: true, precedence: CredentialPrecedenceStatic, want: role},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			log := &logRecorder{}
+			cfg := Config{Path: "/app", AWSRegion: "us-east-1", CredentialPrecedence: tc.precedence, Logger: log}
+
+			if tc.keys {
+				cfg.AWSAccessKeyID, cfg.AWSSecretAccessKey = "AKID", "SECRET"
+			}
+
+			if tc.arn {
+				cfg.AWSRoleARN = "arn:aws:iam::123456789012:role/app"
+			}
+
+			_, awsCfg, err := newClient(&cfg)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !aws.IsCredentialsProvider(awsCfg.Credentials, tc.want) {
+				t.Fatalf("got %T, want %T", awsCfg.Credentials, tc.want)
+			}
+
+			if warned := log.contains("both static keys and a role ARN"); warned != tc.warns {
+				t.Fatalf("got warning %v, want %v", warned, tc.warns)
+			}
+		})
+	}
+}
+
+func TestUnknownCredentialPrecedence(t *testing.T) {
+	cfg := Config{
+		Path:                 "/app",
+		AWSRegion:            "us-east-1",
+		AWSAccessKeyID:       "AKID",
+		AWSSecretAccessKey:   "SECRET",
+		AWSRoleARN:           "arn:aws:iam::123456789012:role/app",
+		CredentialPrecedence: "Static",
+	}
+
+	if ps := Provider(cfg, nil); ps != nil {
+		t.Fatal("got a provider for an unknown precedence")
+	}
+
+	if err := validate(cfg); err == nil || !strings.Contains(err.Error(), `"Static"`) {
+		t.Fatalf("got %v, want the unknown precedence rejected", err)
+	}
+}
+
+func TestRoleDuration(t *testing.T) {
+	var duration string
+
+	// Answer AssumeRole like STS, recording the requested duration
+	sts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		duration = r.Form.Get("DurationSeconds")
+
+		w.Header().Set("Content-Type", "text/xml")
+		fmt.Fprintf(w, `<AssumeRoleResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/"><AssumeRoleResult><Credentials><AccessKeyId>ROLE</AccessKeyId><SecretAccessKey>SECRET</SecretAccessKey><SessionToken>TOKEN</SessionToken><Expiration>%s</Expiration></Credentials></AssumeRoleResult></AssumeRoleResponse>`, time.Now().Add(2*time.Hour).UTC().Format(time.RFC3339))
+	}))
+	defer sts.Close()
+
+	cfg := Config{
+		Path:               "/app",
+		AWSRegion:          "us-east-1",
+		AWSAccessKeyID:     "AKID",
+		AWSSecretAccessKey: "SECRET",
+		AWSRoleARN:         "arn:aws:iam::123456789012:role/app",
+		AWSRoleDuration:    2 * time.Hour,
+		STSEndpointURL:     sts.URL,
+	}
+
+	_, awsCfg, err := newClient(&cfg)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := awsCfg.Credentials.Retrieve(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if duration != "7200" {
+		t.Fatalf("requested DurationSeconds %q, want 7200", duration)
+	}
+
+	for _, d := range []time.Duration{time.Minute, 13 * time.Hour} {
+		cfg.AWSRoleDuration = d
+
+		if err := validate(cfg); err == nil {
+			t.Errorf("accepted a role duration of %s", d)
+		}
+	}
+}
+
+// certCredentials stands in for a certificate based provider such as the
+// Roles Anywhere signing helper.
+type certCredentials struct{}
+
+func (certCredentials) Retrieve(context.Context) (aws.Credentials, error) {
+	return aws.Credentials{AccessKeyID: "CERT", SecretAccessKey: "SECRET", SessionToken: "TOKEN", Source: "rolesanywhere"}, nil
+}
+
+func TestInjectedCredentials(t *testing.T) {
+	srv := newSSMServer(t, newFakeSSM("/app/a", "1"))
+
+	cfg := srv.config("/app")
+	cfg.AWSAccessKeyID, cfg.AWSSecretAccessKey = "", ""
+	cfg.Credentials = certCredentials{}
+
+	ps := Provider(cfg, nil)
+
+	if ps == nil {
+		t.Fatal("Provider failed")
+	}
+
+	if _, err := ps.Read(); err != nil {
+		t.Fatal(err)
+	}
+
+	if auth := srv.last().Header.Get("Authorization"); !strings.Contains(auth, "Credential=CERT/") {
+		t.Fatalf("signed with %q, want the injected credentials", auth)
+	}
+
+	if token := srv.last().Header.Get("X-Amz-Security-Token"); token != "TOKEN" {
+		t.Fatalf("got session token %q", token)
+	}
+
+	// Static keys still win
+	cfg.AWSAccessKeyID, cfg.AWSSecretAccessKey = "AKID", "SECRET"
+
+	if err := ps.UpdateConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ps.Read(); err != nil {
+		t.Fatal(err)
+	}
+
+	if auth := srv.last().Header.Get("Authorization"); !strings.Contains(auth, "Credential=AKID/") {
+		t.Fatalf("signed with %q, want the static keys", auth)
+	}
+}
+
+func TestRequireCredentials(t *testing.T) {
+	srv := newSSMServer(t, newFakeSSM("/app/a", "1"))
+
+	for _, tc := range []struct {
+		name  string
+		creds aws.CredentialsProvider
+		want  string
+	}{
+		{"failing", aws.CredentialsProviderFunc(func(context.Context) (aws.Credentials, error) {
+			return aws.Credentials{}, errors.New("no EC2 IMDS role found")
+		}), "no AWS credentials found"},
+		{"empty", aws.CredentialsProviderFunc(func(context.Context) (aws.Credentials, error) {
+			return aws.Credentials{}, nil
+		}), "credentials are empty"},
+		{"anonymous", aws.AnonymousCredentials{}, "credentials are anonymous"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := srv.config("/app")
+			cfg.AWSAccessKeyID, cfg.AWSSecretAccessKey = "", ""
+			cfg.Credentials = tc.creds
+			cfg.RequireCredentials = true
+
+			if Provider(cfg, nil) != nil {
+				t.Fatal("got a provider without credentials")
+			}
+
+			// Lazy providers fail the read with the same error
+			cfg.Lazy = true
+
+			_, err := Provider(cfg, nil).Read()
+
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("got %v, want %q", err, tc.want)
+			}
+
+			srv.mu.Lock()
+			defer srv.mu.Unlock()
+
+			if len(srv.requests) > 0 {
+				t.Fatal("called SSM without credentials")
+			}
+		})
+	}
+}
+
+func TestUpdateConfigRacingLazyBuild(t *testing.T) {
+	srv := newSSMServer(t, newFakeSSM("/app/a", "1"))
+
+	for i := 0; i < 20; i++ {
+		cfg := srv.config("/app")
+		cfg.Lazy = true
+
+		ps := Provider(cfg, nil)
+
+		updated := srv.config("/app")
+		updated.Lazy = true
+		updated.AWSRegion = "eu-west-1"
+
+		// Build the lazy client while the region changes
+		var wg sync.WaitGroup
+		wg.Add(2)
+
+		go func() {
+			defer wg.Done()
+			ps.ensureClient()
+		}()
+
+		go func() {
+			defer wg.Done()
+
+			if err := ps.UpdateConfig(updated); err != nil {
+				t.Error(err)
+			}
+		}()
+
+		wg.Wait()
+
+		ps.mu.RLock()
+		client, region := ps.client, ps.awsConfig.Region
+		ps.mu.RUnlock()
+
+		if client != nil && region != "eu-west-1" {
+			t.Fatalf("kept a client for %s after switching to eu-west-1", region)
+		}
+
+		if _, err := ps.Read(); err != nil {
+			t.Fatal(err)
+		}
+
+		if region := signingRegion(srv.last().Request); region != "eu-west-1" {
+			t.Fatalf("read signed for %s, want eu-west-1", region)
+		}
+	}
+}
+
+func TestDefaultChainCredentialsRefreshEarly(t *testing.T) {
+	dir := t.TempDir()
+	calls := filepath.Join(dir, "calls")
+
+	// A credential process whose credentials expire within the expiry window
+	script := filepath.Join(dir, "creds.sh")
+	expires := time.Now().Add(credentialsExpiryWindow / 2).UTC().Format(time.RFC3339)
+
+	if err := os.WriteFile(script, []byte(fmt.Sprintf("#!/bin/sh\necho x >> %s\necho '{\"Version\":1,\"AccessKeyId\":\"PROC\",\"SecretAccessKey\":\"SECRET\",\"Expiration\":\"%s\"}'\n", calls, expires)), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	profile := filepath.Join(dir, "config")
+
+	if err := os.WriteFile(profile, []byte("[default]\ncredential_process = "+script+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("AWS_CONFIG_FILE", profile)
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(dir, "credentials"))
+	t.Setenv("AWS_PROFILE", "")
+	t.Setenv("AWS_ACCESS_KEY_ID", "")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "")
+	t.Setenv("AWS_SESSION_TOKEN", "")
+
+	for _, shared := range []bool{false, true} {
+		ResetDefaultConfigCache()
+		os.Remove(calls)
+
+		cfg := Config{Path: "/app", AWSRegion: "us-east-1", CacheDefaultConfig: shared}
+		_, awsCfg, err := newClient(&cfg)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for i := 0; i < 2; i++ {
+			if _, err := awsCfg.Credentials.Retrieve(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		// Credentials inside the window count as expired and are fetched again
+		out, _ := os.ReadFile(calls)
+
+		if n := bytes.Count(out, []byte("x")); n != 2 {
+			t.Fatalf("CacheDefaultConfig %t: credential process ran %d times, want 2", shared, n)
+		}
+	}
+
+	ResetDefaultConfigCache()
+}
@@ -0,0 +1,52 @@
+package paramstore
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Type names supported in Config.ExpectedTypes.
+const (
+	TypeString   = "string"
+	TypeInt      = "int"
+	TypeFloat    = "float"
+	TypeBool     = "bool"
+	TypeDuration = "duration"
+	// TypeStrings splits a comma-separated value into a []string.
+	TypeStrings = "strings"
+)
+
+// SplitStringList is a TypeHandlers entry splitting StringList values at their
+// commas into a []string.
+func SplitStringList(s string) (interface{}, error) {
+	return strings.Split(s, ","), nil
+}
+
+// coerce converts value to the named type. Values that aren't strings are
+// returned unchanged, since the transformer has already typed them.
+func coerce(value interface{}, typ string) (interface{}, error) {
+	s, ok := value.(string)
+
+	if !ok {
+		return value, nil
+	}
+
+	switch typ {
+	case TypeString:
+		return s, nil
+	case TypeInt:
+		return strconv.Atoi(strings.TrimSpace(s))
+	case TypeFloat:
+		return strconv.ParseFloat(strings.TrimSpace(s), 64)
+	case TypeBool:
+		return strconv.ParseBool(strings.TrimSpace(s))
+	case TypeDuration:
+		return time.ParseDuration(strings.TrimSpace(s))
+	case TypeStrings:
+		return strings.Split(s, ","), nil
+	default:
+		return nil, fmt.Errorf("unsupported type %q", typ)
+	}
+}
@@ -0,0 +1,26 @@
+package paramstore
+
+import (
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+// redacted replaces secret values in logs and exports.
+const redacted = "[REDACTED]"
+
+// debugPage logs the parameters and continuation token of a single
+// GetParametersByPath page. SecureString values are never logged.
+func debugPage(l Logger, page int, result *ssm.GetParametersByPathOutput) {
+	logf(l, "paramstore: page %d: %d parameters, next token %q", page, len(result.Parameters), aws.ToString(result.NextToken))
+
+	for _, param := range result.Parameters {
+		value := aws.ToString(param.Value)
+
+		if param.Type == types.ParameterTypeSecureString {
+			value = redacted
+		}
+
+		logf(l, "paramstore: page %d: %s type=%s version=%d value=%q", page, aws.ToString(param.Name), param.Type, param.Version, value)
+	}
+}
@@ -0,0 +1,189 @@
+package paramstore
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	"github.com/aws/smithy-go"
+)
+
+// getParametersBatchSize is the maximum number of names GetParameters accepts.
+const getParametersBatchSize = 10
+
+// isDecryptionError reports whether err was caused by SSM failing to decrypt
+// a SecureString through KMS.
+func isDecryptionError(err error) bool {
+	var invalidKeyID *types.InvalidKeyId
+
+	if errors.As(err, &invalidKeyID) {
+		return true
+	}
+
+	var apiErr smithy.APIError
+
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+
+	if strings.HasPrefix(apiErr.ErrorCode(), "KMS") {
+		return true
+	}
+
+	msg := strings.ToLower(apiErr.ErrorMessage())
+
+	return apiErr.ErrorCode() == "AccessDeniedException" && strings.Contains(msg, "kms")
+}
+
+// hasSecureStrings reports whether any of params is a SecureString.
+func hasSecureStrings(params []types.Parameter) bool {
+	for _, param := range params {
+		if param.Type == types.ParameterTypeSecureString {
+			return true
+		}
+	}
+
+	return false
+}
+
+// readTolerant reads the path without decryption and then decrypts the
+// SecureStrings in batches, falling back to one by one for failing batches.
+// Parameters that still can't be decrypted are skipped and logged.
+func (ps *ParamStore) readTolerant(ctx context.Context, cfg Config, client SSMClient, input ssm.GetParametersByPathInput) ([]types.Parameter, error) {
+	input.NextToken = nil
+	input.WithDecryption = aws.Bool(false)
+
+	params, err := ps.fetchPages(ctx, cfg, client, &input)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// Collect SecureString names
+	var names []string
+
+	for _, param := range params {
+		if param.Type == types.ParameterTypeSecureString && param.Name != nil {
+			names = append(names, *param.Name)
+		}
+	}
+
+	decrypted := make(map[string]types.Parameter, len(names))
+
+	for start := 0; start < len(names); start += getParametersBatchSize {
+		end := min(start+getParametersBatchSize, len(names))
+		batch := names[start:end]
+
+		result, err := client.GetParameters(ctx, &ssm.GetParametersInput{
+			Names:          batch,
+			WithDecryption: aws.Bool(true),
+		})
+
+		if err == nil {
+			for _, param := range result.Parameters {
+				decrypted[aws.ToString(param.Name)] = param
+			}
+
+			continue
+		}
+
+		if !isDecryptionError(err) {
+			return nil, err
+		}
+
+		// Retry the failing batch one parameter at a time
+		for _, name := range batch {
+			result, err := client.GetParameter(ctx, &ssm.GetParameterInput{
+				Name:           aws.String(name),
+				WithDecryption: aws.Bool(true),
+			})
+
+			if err != nil {
+				if !isDecryptionError(err) {
+					return nil, err
+				}
+
+				warnf(ctx, cfg, "skipping %s: unable to decrypt: %v", name, err)
+
+				if stats := statsFrom(ctx); stats != nil {
+					stats.decryptionFailures = append(stats.decryptionFailures, name)
+				}
+
+				continue
+			}
+
+			decrypted[name] = *result.Parameter
+		}
+	}
+
+	// Replace SecureStrings with their decrypted counterparts
+	out := params[:0]
+
+	for _, param := range params {
+		// Leave parameters without a name for transform to report
+		if param.Type != types.ParameterTypeSecureString || param.Name == nil {
+			out = append(out, param)
+
+			continue
+		}
+
+		if p, ok := decrypted[*param.Name]; ok {
+			out = append(out, p)
+		}
+	}
+
+	return out, nil
+}
+
+// DecryptionFailures returns the names of the SecureStrings the last Read
+// skipped because they couldn't be decrypted with TolerateDecryptionErrors
+// enabled, in the order they were tried.
+func (ps *ParamStore) DecryptionFailures() []string {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	return append([]string(nil), ps.decryptionFailures...)
+}
+
+// decryptSelected replaces the SecureStrings in params matching
+// cfg.DecryptPredicate with their decrypted values, and returns the names of
+// those it replaced.
+func decryptSelected(ctx context.Context, cfg Config, client SSMClient, params []types.Parameter) ([]types.Parameter, map[string]bool, error) {
+	var names []string
+
+	for _, param := range params {
+		if param.Type == types.ParameterTypeSecureString && cfg.DecryptPredicate(aws.ToString(param.Name)) {
+			names = append(names, aws.ToString(param.Name))
+		}
+	}
+
+	if len(names) == 0 {
+		return params, nil, nil
+	}
+
+	fetched, err := getParameters(ctx, cfg, client, names, true)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	decrypted := make(map[string]types.Parameter, len(fetched))
+
+	for _, param := range fetched {
+		decrypted[aws.ToString(param.Name)] = param
+	}
+
+	replaced := make(map[string]bool, len(decrypted))
+
+	for i, param := range params {
+		if p, ok := decrypted[aws.ToString(param.Name)]; ok {
+			params[i] = p
+			replaced[aws.ToString(param.Name)] = true
+		}
+	}
+
+	return params, replaced, nil
+}
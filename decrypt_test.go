@@ -0,0 +1,30 @@
+package paramstore
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+func TestStrictDecryption(t *testing.T) {
+	fake := newFakeSSM("/app/a", "1")
+	fake.set("/app/password", "hunter2", types.ParameterTypeSecureString)
+
+	_, err := ProviderWithClient(Config{Path: "/app", StrictDecryption: true}, nil, fake).Read()
+
+	if err == nil || !strings.Contains(err.Error(), "WithDecryption") {
+		t.Fatalf("got %v, want an error asking for decryption", err)
+	}
+
+	// Decrypting satisfies it
+	mp, err := ProviderWithClient(Config{Path: "/app", StrictDecryption: true, WithDecryption: true}, nil, fake).Read()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := mp["app"].(map[string]interface{})["password"]; got != "hunter2" {
+		t.Fatalf("got %v, want the decrypted value", got)
+	}
+}
@@ -0,0 +1,112 @@
+package paramstore
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DelimiterConflict decides what happens when a non-"/" Delimiter occurs
+// literally inside a segment of an SSM parameter name, e.g. "/app/v1.2/url"
+// with a "." delimiter. Since maps.Unflatten has no escape syntax, such a
+// segment would otherwise be split into extra nesting levels once the
+// transformer turns "/" into the delimiter.
+type DelimiterConflict string
+
+const (
+	// DelimiterConflictIgnore passes names through unchanged and lets
+	// unflatten split them. This is the default.
+	DelimiterConflictIgnore DelimiterConflict = ""
+	// DelimiterConflictError makes Read fail on the first conflicting name.
+	DelimiterConflictError DelimiterConflict = "error"
+	// DelimiterConflictReplace replaces the delimiter inside segments with
+	// DelimiterReplacement before the transformer is called.
+	DelimiterConflictReplace DelimiterConflict = "replace"
+)
+
+// escapeDelimiter applies cfg.DelimiterConflict to an SSM parameter name.
+func escapeDelimiter(name string, cfg Config) (string, error) {
+	// SSM segments can't contain "/", so there is nothing to detect
+	if cfg.DelimiterConflict == DelimiterConflictIgnore || cfg.Delimiter == "/" {
+		return name, nil
+	}
+
+	if !strings.Contains(name, cfg.Delimiter) {
+		return name, nil
+	}
+
+	if cfg.DelimiterConflict == DelimiterConflictError {
+		return "", fmt.Errorf("parameter %s contains the delimiter %q inside a name segment", name, cfg.Delimiter)
+	}
+
+	replacement := cfg.DelimiterReplacement
+
+	if replacement == "" {
+		replacement = "_"
+	}
+
+	return strings.ReplaceAll(name, cfg.Delimiter, replacement), nil
+}
+
+// normalizeKey turns any "/" left in key into delim, collapses repeated
+// delimiters and drops a trailing one. A leading delimiter is kept for
+// KeepLeadingDelimiter to decide on.
+func normalizeKey(key, delim string) string {
+	if delim == "" {
+		return key
+	}
+
+	key = strings.ReplaceAll(key, "/", delim)
+
+	for strings.Contains(key, delim+delim) {
+		key = strings.ReplaceAll(key, delim+delim, delim)
+	}
+
+	return strings.TrimSuffix(key, delim)
+}
+
+// stripSegments drops the first n segments of key, keeping a leading
+// delimiter for KeepLeadingDelimiter to decide on. It returns "" if key has no
+// more than n segments.
+func stripSegments(key, delim string, n int) string {
+	lead := ""
+
+	if strings.HasPrefix(key, delim) {
+		lead = delim
+		key = strings.TrimPrefix(key, delim)
+	}
+
+	parts := strings.SplitN(key, delim, n+1)
+
+	if len(parts) <= n || parts[n] == "" {
+		return ""
+	}
+
+	return lead + parts[n]
+}
+
+// nestUnder prefixes key with the segments of the SSM path, joined by delim,
+// unless key is already below them. A leading delimiter is kept for
+// KeepLeadingDelimiter to decide on.
+func nestUnder(key, path, delim string) string {
+	segments := strings.FieldsFunc(path, func(r rune) bool {
+		return r == '/'
+	})
+
+	if len(segments) == 0 {
+		return key
+	}
+
+	prefix := strings.Join(segments, delim)
+	lead := ""
+
+	if strings.HasPrefix(key, delim) {
+		lead = delim
+		key = strings.TrimPrefix(key, delim)
+	}
+
+	if key == prefix || strings.HasPrefix(key, prefix+delim) {
+		return lead + key
+	}
+
+	return lead + prefix + delim + key
+}
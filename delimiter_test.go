@@ -0,0 +1,127 @@
+package paramstore
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDelimiterConflict(t *testing.T) {
+	fake := newFakeSSM("/app/v1.2/url", "https://example.com")
+	cb := PathTransformer("/app", ".")
+
+	// By default the literal "." splits the segment
+	mp, err := ProviderWithClient(Config{Path: "/app", Recursive: true, Delimiter: "."}, cb, fake).Read()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := mp["v1"].(map[string]interface{})["2"]; !ok {
+		t.Fatalf("got %v, want the segment split at the delimiter", mp)
+	}
+
+	_, err = ProviderWithClient(Config{Path: "/app", Recursive: true, Delimiter: ".", DelimiterConflict: DelimiterConflictError}, cb, fake).Read()
+
+	if err == nil || !strings.Contains(err.Error(), "/app/v1.2/url") {
+		t.Fatalf("got %v, want an error naming the parameter", err)
+	}
+
+	mp, err = ProviderWithClient(Config{Path: "/app", Recursive: true, Delimiter: ".", DelimiterConflict: DelimiterConflictReplace}, cb, fake).Read()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := mp["v1_2"].(map[string]interface{})["url"]; got != "https://example.com" {
+		t.Fatalf("got %v, want the delimiter replaced", mp)
+	}
+}
+
+func TestUnknownDelimiterConflict(t *testing.T) {
+	_, err := ProviderWithClient(Config{Path: "/app", DelimiterConflict: "escape"}, nil, newFakeSSM()).Read()
+
+	if err == nil {
+		t.Fatal("accepted an unknown DelimiterConflict")
+	}
+}
+
+func TestNormalizeDelimiters(t *testing.T) {
+	// An operator typo and a transformer mixing separators
+	fake := newFakeSSM("/app//db/host", "h", "/app/db/port", "5432")
+	cb := func(name string) string {
+		return strings.Replace(name, "/db/port", "./db.port.", 1)
+	}
+
+	mp, err := ProviderWithClient(Config{Path: "/app", Recursive: true, Delimiter: ".", NormalizeDelimiters: true}, cb, fake).Read()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]interface{}{"app": map[string]interface{}{"db": map[string]interface{}{"host": "h", "port": "5432"}}}
+
+	if !reflect.DeepEqual(mp, want) {
+		t.Fatalf("got %v, want %v", mp, want)
+	}
+}
+
+func TestStripSegments(t *testing.T) {
+	fake := newFakeSSM("/app/prod/db/host", "h", "/app/staging/db/host", "s")
+
+	// The environment varies, the depth doesn't
+	for path, host := range map[string]string{"/app/prod": "h", "/app/staging": "s"} {
+		t.Run(path, func(t *testing.T) {
+			cfg := Config{Path: path, Recursive: true, Delimiter: ".", StripSegments: 2}
+
+			mp, err := ProviderWithClient(cfg, PathTransformer("", "."), fake).Read()
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			want := map[string]interface{}{"db": map[string]interface{}{"host": host}}
+
+			if !reflect.DeepEqual(mp, want) {
+				t.Fatalf("got %v, want %v", mp, want)
+			}
+		})
+	}
+}
+
+func TestNestUnderPath(t *testing.T) {
+	fake := newFakeSSM("/app/prod/db/host", "h")
+	cb := PathTransformer("/app/prod", ".")
+	nested := map[string]interface{}{"app": map[string]interface{}{"prod": map[string]interface{}{"db": map[string]interface{}{"host": "h"}}}}
+	stripped := map[string]interface{}{"db": map[string]interface{}{"host": "h"}}
+
+	for name, tc := range map[string]struct {
+		nest bool
+		cb   func(string) string
+		want map[string]interface{}
+	}{
+		"stripped":      {cb: cb, want: stripped},
+		"nested":        {nest: true, cb: cb, want: nested},
+		"already below": {nest: true, cb: PathTransformer("", "."), want: nested},
+	} {
+		t.Run(name, func(t *testing.T) {
+			cfg := Config{Path: "/app/prod", Recursive: true, Delimiter: ".", NestUnderPath: tc.nest}
+
+			mp, err := ProviderWithClient(cfg, tc.cb, fake).Read()
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(mp, tc.want) {
+				t.Fatalf("got %v, want %v", mp, tc.want)
+			}
+		})
+	}
+
+	cfg := Config{Path: "/app/prod", NestUnderPath: true, StripSegments: 1}
+
+	if _, err := ProviderWithClient(cfg, cb, fake).Read(); err == nil {
+		t.Fatal("accepted NestUnderPath with StripSegments")
+	}
+}
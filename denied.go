@@ -0,0 +1,111 @@
+package paramstore
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	"github.com/aws/smithy-go"
+)
+
+// isAccessDenied reports whether err is an IAM AccessDenied error that isn't
+// caused by KMS decryption.
+func isAccessDenied(err error) bool {
+	var apiErr smithy.APIError
+
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+
+	return apiErr.ErrorCode() == "AccessDeniedException" && !isDecryptionError(err)
+}
+
+// readDenied narrows a read that failed with AccessDenied. It reads the direct
+// children of the path and, for recursive reads, discovers the sub-branches
+// via DescribeParameters and reads each of them on its own. Branches that are
+// still denied are logged and skipped.
+func (ps *ParamStore) readDenied(ctx context.Context, cfg Config, client SSMClient, input ssm.GetParametersByPathInput) ([]types.Parameter, error) {
+	path := aws.ToString(input.Path)
+
+	input.NextToken = nil
+	input.Recursive = aws.Bool(false)
+
+	// Read the direct children
+	params, err := ps.fetchPages(ctx, cfg, client, &input)
+
+	if err != nil {
+		if !isAccessDenied(err) {
+			return nil, err
+		}
+
+		warnf(ctx, cfg, "skipping %s: %v", path, err)
+	}
+
+	if !cfg.Recursive {
+		return params, nil
+	}
+
+	// Discover the sub-branches
+	branchCfg := cfg
+	branchCfg.Path = path
+
+	metas, err := describeParameters(ctx, branchCfg, client)
+
+	if err != nil {
+		if !isAccessDenied(err) {
+			return nil, err
+		}
+
+		warnf(ctx, cfg, "unable to discover branches below %s: %v", path, err)
+
+		return params, nil
+	}
+
+	prefix := strings.TrimSuffix(path, "/") + "/"
+	branches := make(map[string]bool)
+
+	for _, meta := range metas {
+		rel := strings.TrimPrefix(aws.ToString(meta.Name), prefix)
+
+		if i := strings.Index(rel, "/"); i > 0 {
+			branches[prefix+rel[:i]] = true
+		}
+	}
+
+	sorted := make([]string, 0, len(branches))
+
+	for branch := range branches {
+		sorted = append(sorted, branch)
+	}
+
+	sort.Strings(sorted)
+
+	// Read every branch recursively, narrowing again where needed
+	for _, branch := range sorted {
+		branchInput := input
+		branchInput.Path = aws.String(branch)
+		branchInput.Recursive = aws.Bool(true)
+
+		fetched, err := ps.fetchPages(ctx, cfg, client, &branchInput)
+
+		if err != nil {
+			if !isAccessDenied(err) {
+				return nil, err
+			}
+
+			fetched, err = ps.readDenied(ctx, cfg, client, branchInput)
+
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		params = append(params, fetched...)
+	}
+
+	return params, nil
+}
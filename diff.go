@@ -0,0 +1,156 @@
+package paramstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// ChangeType describes how a key differs between two configs.
+type ChangeType string
+
+const (
+	ChangeAdded   ChangeType = "added"
+	ChangeUpdated ChangeType = "updated"
+	ChangeDeleted ChangeType = "deleted"
+)
+
+// Change is a single difference between two flat configs. Old is nil for
+// added keys and New is nil for deleted ones.
+type Change struct {
+	Key  string
+	Type ChangeType
+	Old  interface{}
+	New  interface{}
+}
+
+// Diff compares two flat configs and returns the changes needed to get from
+// old to new, sorted by key. SecretString values are compared by their
+// revealed value.
+func Diff(old, new map[string]interface{}) []Change {
+	var changes []Change
+
+	for key, value := range new {
+		prev, ok := old[key]
+
+		switch {
+		case !ok:
+			changes = append(changes, Change{Key: key, Type: ChangeAdded, New: value})
+		case !equalValues(prev, value):
+			changes = append(changes, Change{Key: key, Type: ChangeUpdated, Old: prev, New: value})
+		}
+	}
+
+	for key, value := range old {
+		if _, ok := new[key]; !ok {
+			changes = append(changes, Change{Key: key, Type: ChangeDeleted, Old: value})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Key < changes[j].Key
+	})
+
+	return changes
+}
+
+func equalValues(a, b interface{}) bool {
+	if s, ok := a.(SecretString); ok {
+		a = s.Reveal()
+	}
+
+	if s, ok := b.(SecretString); ok {
+		b = s.Reveal()
+	}
+
+	return reflect.DeepEqual(a, b)
+}
+
+// CompareWithFile reads the current parameters and diffs them against a
+// local config file, which is treated as the source of truth: keys only in
+// SSM are reported as added, keys only in the file as deleted. The file is
+// parsed by extension (.json, .yaml, .yml or .env) and flattened with the
+// provider's delimiter. Values on both sides are compared as strings, since SSM
+// has no other value types.
+func (ps *ParamStore) CompareWithFile(path string) ([]Change, error) {
+	res, cfg, err := ps.read(context.Background(), nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	k, err := loadFile(path, cfg.Delimiter)
+
+	if err != nil {
+		return nil, err
+	}
+
+	want, err := stringifyValues(k.All())
+
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	got, err := stringifyValues(res.Flat)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return Diff(want, got), nil
+}
+
+// LastChanges returns how the config of the last read differs from the one
+// before it, including the re-reads of Watch with WatchRefresh and
+// ChangesSince with updateBaseline. After the first read, or the first after
+// Reset, every key is reported as added.
+func (ps *ParamStore) LastChanges() []Change {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	return append([]Change(nil), ps.changes...)
+}
+
+// ChangesSince reads the parameters again and returns how they differ from
+// the last read, like the changes Watch reports but on the caller's schedule.
+// With updateBaseline, the fresh read becomes the new baseline for later
+// calls, Watch and the accessors; otherwise the baseline is left untouched.
+func (ps *ParamStore) ChangesSince(updateBaseline bool) ([]Change, error) {
+	ps.mu.RLock()
+	old := ps.flat
+	ps.mu.RUnlock()
+
+	if old == nil {
+		return nil, errors.New("no parameters have been read yet")
+	}
+
+	ctx := context.Background()
+
+	if !updateBaseline {
+		ctx = withoutBaseline(ctx)
+	}
+
+	res, _, err := ps.read(ctx, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return Diff(old, res.Flat), nil
+}
+
+// baselineKey marks reads that must not replace the stored baseline.
+type baselineKey struct{}
+
+func withoutBaseline(ctx context.Context) context.Context {
+	return context.WithValue(ctx, baselineKey{}, true)
+}
+
+// storesBaseline reports whether a read with ctx should store its result.
+func storesBaseline(ctx context.Context) bool {
+	skip, _ := ctx.Value(baselineKey{}).(bool)
+
+	return !skip
+}
@@ -0,0 +1,58 @@
+// Package paramstore implements a koanf provider for AWS SSM Parameter Store.
+//
+// Parameters below Config.Path are read with GetParametersByPath, passed
+// through the optional key transformer and unflattened with Config.Delimiter:
+//
+//	provider := paramstore.Provider(paramstore.Config{
+//		Path:      "/app/prod",
+//		Recursive: true,
+//		Delimiter: ".",
+//	}, func(key string) string {
+//		return strings.ReplaceAll(strings.TrimPrefix(key, "/app/prod/"), "/", ".")
+//	})
+//
+//	k := koanf.New(".")
+//	err := k.Load(provider, nil)
+//
+// The provider is written against koanf v2, but since the Provider interface
+// is the same in koanf v1, it loads into both.
+//
+// # Public parameters
+//
+// The public parameters AWS publishes below /aws/service (AMI IDs, region
+// and service listings) are read the same way and need no extra IAM
+// permissions beyond ssm:GetParametersByPath. These trees can be large and
+// deeply nested, so enable Recursive and consider MaxDepth. Their names often
+// contain dots, which clash with a "." delimiter; use DelimiterConflict to
+// handle that:
+//
+//	provider := paramstore.Provider(paramstore.Config{
+//		Path:              "/aws/service/ami-amazon-linux-latest",
+//		Recursive:         true,
+//		Delimiter:         ".",
+//		DelimiterConflict: paramstore.DelimiterConflictReplace,
+//	}, func(key string) string {
+//		key = strings.TrimPrefix(key, "/aws/service/ami-amazon-linux-latest/")
+//		return strings.ReplaceAll(key, "/", ".")
+//	})
+//
+// # IAM Roles Anywhere
+//
+// Workloads outside AWS can authenticate with an X.509 certificate through
+// IAM Roles Anywhere instead of long-lived keys. Pass any credentials
+// provider as Config.Credentials, for example the AWS signing helper run as
+// a credential process:
+//
+//	provider := paramstore.Provider(paramstore.Config{
+//		Path:      "/app/prod",
+//		AWSRegion: "eu-west-1",
+//		Credentials: processcreds.NewProvider("aws_signing_helper credential-process" +
+//			" --certificate /etc/pki/app.pem --private-key /etc/pki/app.key" +
+//			" --trust-anchor-arn arn:aws:rolesanywhere:eu-west-1:123456789012:trust-anchor/TA" +
+//			" --profile-arn arn:aws:rolesanywhere:eu-west-1:123456789012:profile/PROFILE" +
+//			" --role-arn arn:aws:iam::123456789012:role/app"),
+//	}, nil)
+//
+// The same helper can also be configured as credential_process in a shared
+// config profile, which the default chain picks up without any code.
+package paramstore
@@ -0,0 +1,42 @@
+package paramstore
+
+import (
+	"fmt"
+
+	"github.com/knadh/koanf/parsers/dotenv"
+)
+
+// expandDotenv parses value as KEY=VALUE lines and returns the entries keyed
+// below key. Quoting, comments and export prefixes are handled like in .env
+// files. SecretString values produce SecretString entries.
+func expandDotenv(key string, value interface{}, delim string) (map[string]interface{}, error) {
+	var raw string
+
+	switch v := value.(type) {
+	case string:
+		raw = v
+	case SecretString:
+		raw = v.Reveal()
+	default:
+		return nil, fmt.Errorf("key %s has a %T value, dotenv expansion needs a string", key, value)
+	}
+
+	entries, err := dotenv.Parser().Unmarshal([]byte(raw))
+
+	if err != nil {
+		return nil, fmt.Errorf("key %s is not valid dotenv: %w", key, err)
+	}
+
+	out := make(map[string]interface{}, len(entries))
+
+	for k, v := range entries {
+		// Keep wrapped secrets wrapped
+		if _, ok := value.(SecretString); ok {
+			v = SecretString(fmt.Sprint(v))
+		}
+
+		out[key+delim+k] = v
+	}
+
+	return out, nil
+}
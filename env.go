@@ -0,0 +1,56 @@
+package paramstore
+
+import (
+	"os"
+	"strconv"
+	"time"
+)
+
+// ConfigFromEnv builds a Config from environment variables:
+//
+//	AWS_REGION                   AWSRegion
+//	PARAMSTORE_PATH              Path
+//	PARAMSTORE_DELIMITER         Delimiter
+//	PARAMSTORE_RECURSIVE         Recursive (strconv.ParseBool)
+//	PARAMSTORE_WITH_DECRYPTION   WithDecryption (strconv.ParseBool)
+//	PARAMSTORE_MAX_DEPTH         MaxDepth
+//	PARAMSTORE_ROLE_ARN          AWSRoleARN
+//	PARAMSTORE_WATCH_INTERVAL    WatchInterval (time.ParseDuration)
+//	PARAMSTORE_SSM_ENDPOINT_URL  SSMEndpointURL
+//	PARAMSTORE_STS_ENDPOINT_URL  STSEndpointURL
+//
+// Unset variables and values that don't parse leave the field at its zero
+// value, so the usual defaults apply. Static credentials are left to the AWS
+// SDK, which reads them from the environment itself. Fields set on the
+// returned Config afterwards take precedence over the environment:
+//
+//	cfg := paramstore.ConfigFromEnv()
+//	cfg.Recursive = true
+func ConfigFromEnv() Config {
+	cfg := Config{
+		AWSRegion:      os.Getenv("AWS_REGION"),
+		Path:           os.Getenv("PARAMSTORE_PATH"),
+		Delimiter:      os.Getenv("PARAMSTORE_DELIMITER"),
+		AWSRoleARN:     os.Getenv("PARAMSTORE_ROLE_ARN"),
+		SSMEndpointURL: os.Getenv("PARAMSTORE_SSM_ENDPOINT_URL"),
+		STSEndpointURL: os.Getenv("PARAMSTORE_STS_ENDPOINT_URL"),
+	}
+
+	if v, err := strconv.ParseBool(os.Getenv("PARAMSTORE_RECURSIVE")); err == nil {
+		cfg.Recursive = v
+	}
+
+	if v, err := strconv.ParseBool(os.Getenv("PARAMSTORE_WITH_DECRYPTION")); err == nil {
+		cfg.WithDecryption = v
+	}
+
+	if v, err := strconv.Atoi(os.Getenv("PARAMSTORE_MAX_DEPTH")); err == nil {
+		cfg.MaxDepth = v
+	}
+
+	if v, err := time.ParseDuration(os.Getenv("PARAMSTORE_WATCH_INTERVAL")); err == nil {
+		cfg.WatchInterval = v
+	}
+
+	return cfg
+}
@@ -0,0 +1,63 @@
+package paramstore
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+// Export reads all parameters and writes them to a local file in the given
+// format: "json" or "yaml" for the nested config, "dotenv" for flat
+// KEY=VALUE lines keyed by the delimiter-joined keys. SecureString values are
+// written as "[REDACTED]" unless includeSecrets is set. The file is created
+// with 0600 permissions.
+func (ps *ParamStore) Export(ctx context.Context, path, format string, includeSecrets bool) error {
+	parser, err := parserFor(format)
+
+	if err != nil {
+		return err
+	}
+
+	res, cfg, err := ps.read(ctx, nil)
+
+	if err != nil {
+		return err
+	}
+
+	flat := exportValues(res, includeSecrets)
+
+	var b []byte
+
+	switch strings.ToLower(format) {
+	case "env", "dotenv":
+		b, err = parser.Marshal(flat)
+	default:
+		b, err = parser.Marshal(unflatten(cfg, flat))
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, b, 0o600)
+}
+
+// exportValues returns the flat values of res with secrets either revealed
+// or redacted.
+func exportValues(res *Result, includeSecrets bool) map[string]interface{} {
+	flat := revealSecrets(res.Flat)
+
+	if includeSecrets {
+		return flat
+	}
+
+	for key := range flat {
+		if res.sources[key].Type == types.ParameterTypeSecureString {
+			flat[key] = redacted
+		}
+	}
+
+	return flat
+}
@@ -0,0 +1,495 @@
+package paramstore
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	"github.com/aws/smithy-go"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+)
+
+// fakeSSM is an in-memory Backend. GetParametersByPath pages through the
+// parameters sorted by name, pageSize at a time.
+type fakeSSM struct {
+	mu       sync.Mutex
+	params   map[string]types.Parameter
+	pageSize int
+	// pages replaces the GetParametersByPath responses if set, one page per
+	// call, to simulate misbehaving backends
+	pages [][]types.Parameter
+	// omitARN drops the ARNs from GetParametersByPath, like some
+	// SSM-compatible backends
+	omitARN bool
+	// patterns holds the AllowedPattern DescribeParameters reports by name
+	patterns map[string]string
+	// err fails every read call if set, as does a non-nil result of
+	// errFunc
+	err       error
+	errFunc   func() error
+	calls     map[string]int
+	inputs    []ssm.GetParametersByPathInput
+	describes []ssm.DescribeParametersInput
+	puts      []ssm.PutParameterInput
+}
+
+func newFakeSSM(kv ...string) *fakeSSM {
+	f := &fakeSSM{
+		params:   make(map[string]types.Parameter),
+		pageSize: 10,
+		calls:    make(map[string]int),
+	}
+
+	for i := 0; i+1 < len(kv); i += 2 {
+		f.set(kv[i], kv[i+1], types.ParameterTypeString)
+	}
+
+	return f
+}
+
+// set stores a new version of the named parameter.
+func (f *fakeSSM) set(name, value string, typ types.ParameterType) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.params[name] = types.Parameter{
+		Name:             aws.String(name),
+		Value:            aws.String(value),
+		Type:             typ,
+		Version:          f.params[name].Version + 1,
+		ARN:              aws.String(fakeARN(name)),
+		DataType:         aws.String("text"),
+		LastModifiedDate: aws.Time(time.Now()),
+	}
+}
+
+func (f *fakeSSM) remove(name string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	delete(f.params, name)
+}
+
+func (f *fakeSSM) setErr(err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.err = err
+}
+
+func (f *fakeSSM) setErrFunc(fn func() error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.errFunc = fn
+}
+
+// failure returns the error read calls fail with, if any.
+func (f *fakeSSM) failure() error {
+	if f.errFunc != nil {
+		return f.errFunc()
+	}
+
+	return f.err
+}
+
+func (f *fakeSSM) count(op string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.calls[op]
+}
+
+func (f *fakeSSM) lastInput() ssm.GetParametersByPathInput {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.inputs[len(f.inputs)-1]
+}
+
+func fakeARN(name string) string {
+	return "arn:aws:ssm:us-east-1:123456789012:parameter" + name
+}
+
+// below returns the parameters below path, sorted by name.
+func (f *fakeSSM) below(path string, recursive bool) []types.Parameter {
+	prefix := strings.TrimSuffix(path, "/") + "/"
+
+	var out []types.Parameter
+
+	for name, param := range f.params {
+		rel, ok := strings.CutPrefix(name, prefix)
+
+		if !ok || (!recursive && strings.Contains(rel, "/")) {
+			continue
+		}
+
+		out = append(out, param)
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		return aws.ToString(out[i].Name) < aws.ToString(out[j].Name)
+	})
+
+	return out
+}
+
+// asReturned returns param as returned with or without decryption.
+func asReturned(param types.Parameter, decrypt bool) types.Parameter {
+	if param.Type == types.ParameterTypeSecureString && !decrypt {
+		param.Value = aws.String("AQICAH" + strconv.Itoa(len(aws.ToString(param.Value))))
+	}
+
+	return param
+}
+
+func (f *fakeSSM) GetParametersByPath(ctx context.Context, in *ssm.GetParametersByPathInput, _ ...func(*ssm.Options)) (*ssm.GetParametersByPathOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.calls["GetParametersByPath"]++
+	f.inputs = append(f.inputs, *in)
+
+	// Fail like the SDK once the context is done
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := f.failure(); err != nil {
+		return nil, err
+	}
+
+	start, _ := strconv.Atoi(aws.ToString(in.NextToken))
+
+	// Serve the scripted pages
+	if f.pages != nil {
+		out := &ssm.GetParametersByPathOutput{Parameters: f.pages[start]}
+
+		if start+1 < len(f.pages) {
+			out.NextToken = aws.String(strconv.Itoa(start + 1))
+		}
+
+		return out, nil
+	}
+
+	matched := f.below(aws.ToString(in.Path), aws.ToBool(in.Recursive))
+	end := min(start+f.pageSize, len(matched))
+
+	out := &ssm.GetParametersByPathOutput{}
+
+	for _, param := range matched[start:end] {
+		if f.omitARN {
+			param.ARN = nil
+		}
+
+		out.Parameters = append(out.Parameters, asReturned(param, aws.ToBool(in.WithDecryption)))
+	}
+
+	if end < len(matched) {
+		out.NextToken = aws.String(strconv.Itoa(end))
+	}
+
+	return out, nil
+}
+
+func (f *fakeSSM) GetParameters(_ context.Context, in *ssm.GetParametersInput, _ ...func(*ssm.Options)) (*ssm.GetParametersOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.calls["GetParameters"]++
+
+	if err := f.failure(); err != nil {
+		return nil, err
+	}
+
+	out := &ssm.GetParametersOutput{}
+
+	for _, name := range in.Names {
+		if param, ok := f.params[name]; ok {
+			out.Parameters = append(out.Parameters, asReturned(param, aws.ToBool(in.WithDecryption)))
+		} else {
+			out.InvalidParameters = append(out.InvalidParameters, name)
+		}
+	}
+
+	return out, nil
+}
+
+func (f *fakeSSM) GetParameter(_ context.Context, in *ssm.GetParameterInput, _ ...func(*ssm.Options)) (*ssm.GetParameterOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.calls["GetParameter"]++
+
+	if err := f.failure(); err != nil {
+		return nil, err
+	}
+
+	param, ok := f.params[aws.ToString(in.Name)]
+
+	if !ok {
+		return nil, &types.ParameterNotFound{Message: aws.String("parameter not found")}
+	}
+
+	param = asReturned(param, aws.ToBool(in.WithDecryption))
+
+	return &ssm.GetParameterOutput{Parameter: &param}, nil
+}
+
+func (f *fakeSSM) GetParameterHistory(_ context.Context, in *ssm.GetParameterHistoryInput, _ ...func(*ssm.Options)) (*ssm.GetParameterHistoryOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.calls["GetParameterHistory"]++
+
+	param, ok := f.params[aws.ToString(in.Name)]
+
+	if !ok {
+		return nil, &types.ParameterNotFound{Message: aws.String("parameter not found")}
+	}
+
+	return &ssm.GetParameterHistoryOutput{Parameters: []types.ParameterHistory{{
+		Name:             param.Name,
+		Type:             param.Type,
+		Value:            param.Value,
+		Version:          param.Version,
+		LastModifiedDate: param.LastModifiedDate,
+	}}}, nil
+}
+
+func (f *fakeSSM) DescribeParameters(_ context.Context, in *ssm.DescribeParametersInput, _ ...func(*ssm.Options)) (*ssm.DescribeParametersOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.calls["DescribeParameters"]++
+	f.describes = append(f.describes, *in)
+
+	if err := f.failure(); err != nil {
+		return nil, err
+	}
+
+	var path string
+	var recursive bool
+
+	for _, filter := range in.ParameterFilters {
+		if aws.ToString(filter.Key) == "Path" {
+			path = filter.Values[0]
+			recursive = aws.ToString(filter.Option) == "Recursive"
+		}
+	}
+
+	out := &ssm.DescribeParametersOutput{}
+
+	for _, param := range f.below(path, recursive) {
+		out.Parameters = append(out.Parameters, types.ParameterMetadata{
+			Name:             param.Name,
+			Type:             param.Type,
+			DataType:         param.DataType,
+			Version:          param.Version,
+			LastModifiedDate: param.LastModifiedDate,
+			AllowedPattern:   aws.String(f.patterns[aws.ToString(param.Name)]),
+		})
+	}
+
+	return out, nil
+}
+
+func (f *fakeSSM) PutParameter(_ context.Context, in *ssm.PutParameterInput, _ ...func(*ssm.Options)) (*ssm.PutParameterOutput, error) {
+	f.mu.Lock()
+	f.calls["PutParameter"]++
+	f.puts = append(f.puts, *in)
+	f.mu.Unlock()
+
+	f.set(aws.ToString(in.Name), aws.ToString(in.Value), in.Type)
+
+	return &ssm.PutParameterOutput{}, nil
+}
+
+// ssmServer serves GetParametersByPath over HTTP from a fakeSSM, for tests
+// going through a real SDK client. It records every request.
+type ssmServer struct {
+	*httptest.Server
+	fake *fakeSSM
+
+	// hook handles the requests it returns true for instead of the fake
+	hook func(w http.ResponseWriter, r *http.Request) bool
+
+	mu       sync.Mutex
+	requests []recordedRequest
+}
+
+type recordedRequest struct {
+	*http.Request
+	body []byte
+}
+
+func newSSMServer(t *testing.T, fake *fakeSSM) *ssmServer {
+	s := &ssmServer{fake: fake}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+func (s *ssmServer) serve(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+
+		return
+	}
+
+	s.mu.Lock()
+	s.requests = append(s.requests, recordedRequest{r.Clone(context.Background()), body})
+	s.mu.Unlock()
+
+	// Let the hook read the body again
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	if s.hook != nil && s.hook(w, r) {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+
+	if r.Header.Get("X-Amz-Target") != "AmazonSSM.GetParametersByPath" {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"__type":"UnknownOperationException"}`))
+
+		return
+	}
+
+	var in ssm.GetParametersByPathInput
+
+	if err := json.Unmarshal(body, &in); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+
+		return
+	}
+
+	out, err := s.fake.GetParametersByPath(r.Context(), &in)
+
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"__type": "ValidationException", "message": err.Error()})
+
+		return
+	}
+
+	params := make([]map[string]interface{}, 0, len(out.Parameters))
+
+	for _, p := range out.Parameters {
+		params = append(params, map[string]interface{}{
+			"Name":    aws.ToString(p.Name),
+			"Value":   aws.ToString(p.Value),
+			"Type":    string(p.Type),
+			"Version": p.Version,
+			"ARN":     aws.ToString(p.ARN),
+		})
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"Parameters": params,
+		"NextToken":  out.NextToken,
+	})
+}
+
+// signingRegion returns the region r was signed for.
+func signingRegion(r *http.Request) string {
+	scope := strings.SplitN(r.Header.Get("Authorization"), "/", 4)
+
+	if len(scope) < 3 {
+		return ""
+	}
+
+	return scope[2]
+}
+
+// last returns the last request.
+func (s *ssmServer) last() recordedRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.requests[len(s.requests)-1]
+}
+
+// config returns a Config talking to s with static credentials.
+func (s *ssmServer) config(path string) Config {
+	return Config{
+		Path:               path,
+		AWSRegion:          "us-east-1",
+		AWSAccessKeyID:     "AKID",
+		AWSSecretAccessKey: "SECRET",
+		SSMEndpointURL:     s.URL,
+	}
+}
+
+var requestIDs atomic.Int64
+
+// sdkError returns an error shaped like the ones the SDK returns for a failed
+// SSM call, with a new request ID every time.
+func sdkError(code string, status int) error {
+	return &smithy.OperationError{
+		ServiceID:     "SSM",
+		OperationName: "GetParametersByPath",
+		Err: &awshttp.ResponseError{
+			ResponseError: &smithyhttp.ResponseError{
+				Response: &smithyhttp.Response{Response: &http.Response{StatusCode: status}},
+				Err:      &smithy.GenericAPIError{Code: code, Message: code + " message"},
+			},
+			RequestID: fmt.Sprintf("req-%d", requestIDs.Add(1)),
+		},
+	}
+}
+
+// waitFor polls cond until it holds or a second has passed.
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met within a second")
+		}
+
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestFakeSSMPagination(t *testing.T) {
+	fake := newFakeSSM("/app/a", "1", "/app/b", "2", "/app/c", "3")
+	fake.pageSize = 2
+
+	var client SSMClient = fake
+
+	mp, err := ProviderWithClient(Config{Path: "/app"}, nil, client).Read()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := mp["app"].(map[string]interface{}); len(got) != 3 {
+		t.Fatalf("got %v, want 3 keys", got)
+	}
+
+	if n := fake.count("GetParametersByPath"); n != 2 {
+		t.Fatalf("got %d calls, want 2", n)
+	}
+}
@@ -0,0 +1,89 @@
+package paramstore
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/knadh/koanf/parsers/dotenv"
+	"github.com/knadh/koanf/parsers/json"
+	"github.com/knadh/koanf/parsers/yaml"
+	"github.com/knadh/koanf/providers/file"
+	"github.com/knadh/koanf/v2"
+)
+
+// parserFor returns the koanf parser for a file format or extension.
+func parserFor(format string) (koanf.Parser, error) {
+	switch strings.TrimPrefix(strings.ToLower(format), ".") {
+	case "json":
+		return json.Parser(), nil
+	case "yaml", "yml":
+		return yaml.Parser(), nil
+	case "env", "dotenv":
+		return dotenv.Parser(), nil
+	default:
+		return nil, fmt.Errorf("unsupported file format %q", format)
+	}
+}
+
+// loadFile parses a local config file into a koanf instance using delim.
+func loadFile(path, delim string) (*koanf.Koanf, error) {
+	parser, err := parserFor(filepath.Ext(path))
+
+	if err != nil {
+		return nil, err
+	}
+
+	k := koanf.New(delim)
+
+	if err := k.Load(file.Provider(path), parser); err != nil {
+		return nil, fmt.Errorf("loading %s: %w", path, err)
+	}
+
+	return k, nil
+}
+
+// stringifyValues formats the values of a flat map the way SSM would store
+// them. Null values have no SSM form and are an error naming the key.
+func stringifyValues(flat map[string]interface{}) (map[string]interface{}, error) {
+	out := make(map[string]interface{}, len(flat))
+
+	for key, value := range flat {
+		switch v := value.(type) {
+		case string, SecretString:
+			out[key] = v
+		case []interface{}:
+			parts := make([]string, len(v))
+
+			for i, item := range v {
+				if item == nil {
+					return nil, fmt.Errorf("%s: list item %d is null", key, i)
+				}
+
+				parts[i] = stringify(item)
+			}
+
+			out[key] = strings.Join(parts, ",")
+		case nil:
+			return nil, fmt.Errorf("%s: value is null", key)
+		default:
+			out[key] = stringify(v)
+		}
+	}
+
+	return out, nil
+}
+
+// stringify formats a scalar, writing floats in plain decimal notation rather
+// than with an exponent.
+func stringify(value interface{}) string {
+	switch v := value.(type) {
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,88 @@
+package paramstore
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+// FilterByType returns a ParameterFilters entry matching parameters of type t.
+func FilterByType(t types.ParameterType) types.ParameterStringFilter {
+	return types.ParameterStringFilter{
+		Key:    aws.String("Type"),
+		Option: aws.String("Equals"),
+		Values: []string{string(t)},
+	}
+}
+
+// FilterByTag returns a ParameterFilters entry matching parameters whose tag
+// key has one of values.
+func FilterByTag(key string, values ...string) types.ParameterStringFilter {
+	return types.ParameterStringFilter{
+		Key:    aws.String("tag:" + key),
+		Option: aws.String("Equals"),
+		Values: values,
+	}
+}
+
+// FilterByName returns a ParameterFilters entry matching parameter names with
+// option "Equals", "BeginsWith" or "Contains". GetParametersByPath rejects
+// Name filters, so Read fails with it; it is meant for the DescribeParameters
+// based calls like Namespaces and Keys. Use NameBeginsWith to narrow Read by
+// name.
+func FilterByName(option string, values ...string) types.ParameterStringFilter {
+	return types.ParameterStringFilter{
+		Key:    aws.String("Name"),
+		Option: aws.String(option),
+		Values: values,
+	}
+}
+
+// filterOptions lists the options SSM accepts per filter key. Keys not listed
+// are passed on unchecked.
+var filterOptions = map[string][]string{
+	"Name":     {"Equals", "BeginsWith", "Contains"},
+	"Type":     {"Equals"},
+	"KeyId":    {"Equals"},
+	"Label":    {"Equals"},
+	"DataType": {"Equals"},
+	"Tier":     {"Equals"},
+	"tag":      {"Equals"},
+}
+
+// validateFilters checks the options of filters, which default to "Equals"
+// when unset.
+func validateFilters(filters []types.ParameterStringFilter) error {
+	for _, f := range filters {
+		key := aws.ToString(f.Key)
+		option := aws.ToString(f.Option)
+
+		if option == "" {
+			continue
+		}
+
+		if strings.HasPrefix(key, "tag:") {
+			key = "tag"
+		}
+
+		allowed, ok := filterOptions[key]
+
+		if !ok {
+			continue
+		}
+
+		valid := false
+
+		for _, o := range allowed {
+			valid = valid || o == option
+		}
+
+		if !valid {
+			return fmt.Errorf("invalid option %q for parameter filter %s, expected one of %v", option, aws.ToString(f.Key), allowed)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,82 @@
+package paramstore
+
+import "strings"
+
+// KeyCase is applied to keys after the transformer has run and before they
+// are unflattened.
+type KeyCase string
+
+const (
+	// KeyCaseNone leaves keys untouched. This is the default.
+	KeyCaseNone  KeyCase = ""
+	KeyCaseLower KeyCase = "lower"
+	KeyCaseUpper KeyCase = "upper"
+)
+
+func (c KeyCase) apply(key string) string {
+	switch c {
+	case KeyCaseLower:
+		return strings.ToLower(key)
+	case KeyCaseUpper:
+		return strings.ToUpper(key)
+	default:
+		return key
+	}
+}
+
+// EnvTransformer returns a key transformer for Provider that turns SSM names
+// into environment variable style keys: prefix is trimmed from the name, the
+// remaining segments are joined with "_" and upper-cased, and any character
+// that isn't a letter, digit or underscore becomes "_". With prefix "/app",
+// "/app/db/host" becomes "DB_HOST" and "/app/feature-flags/beta" becomes
+// "FEATURE_FLAGS_BETA". The prefix only matches whole segments, so
+// "/apple/x" isn't trimmed and becomes "APPLE_X".
+//
+// The keys are only nested by Read when Delimiter is "_"; any other delimiter
+// keeps them flat.
+func EnvTransformer(prefix string) func(string) string {
+	return func(key string) string {
+		key = trimPathPrefix(key, prefix)
+		key = strings.Trim(key, "/")
+
+		return strings.Map(func(r rune) rune {
+			switch {
+			case r >= 'a' && r <= 'z':
+				return r - 'a' + 'A'
+			case r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_':
+				return r
+			default:
+				return '_'
+			}
+		}, key)
+	}
+}
+
+// PathTransformer returns a key transformer for Provider that trims prefix
+// and any leading "/" from SSM names and converts the remaining "/"
+// separators to delimiter, which may be longer than one character. With
+// prefix "/app" and delimiter "::", "/app/db/host" becomes "db::host". Like
+// for EnvTransformer, the prefix only matches whole segments.
+func PathTransformer(prefix, delimiter string) func(string) string {
+	return func(key string) string {
+		key = trimPathPrefix(key, prefix)
+		key = strings.TrimLeft(key, "/")
+
+		return strings.ReplaceAll(key, "/", delimiter)
+	}
+}
+
+// trimPathPrefix trims prefix from name if name is prefix or below it.
+func trimPathPrefix(name, prefix string) string {
+	prefix = strings.TrimSuffix(prefix, "/")
+
+	if prefix == "" || name == prefix {
+		return strings.TrimPrefix(name, prefix)
+	}
+
+	if strings.HasPrefix(name, prefix+"/") {
+		return name[len(prefix):]
+	}
+
+	return name
+}
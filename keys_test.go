@@ -0,0 +1,133 @@
+package paramstore
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEnvTransformer(t *testing.T) {
+	for _, tc := range []struct {
+		prefix, name, want string
+	}{
+		{"/app", "/app/db/host", "DB_HOST"},
+		{"/app/", "/app/db/host", "DB_HOST"},
+		{"", "/app/db/host", "APP_DB_HOST"},
+		{"/app", "/app/feature-flags/Beta", "FEATURE_FLAGS_BETA"},
+		{"/app", "/app/db.v2/max_conns", "DB_V2_MAX_CONNS"},
+		{"/app", "/apple/x", "APPLE_X"},
+		{"/app", "/other/x", "OTHER_X"},
+	} {
+		if got := EnvTransformer(tc.prefix)(tc.name); got != tc.want {
+			t.Errorf("EnvTransformer(%q)(%q) = %q, want %q", tc.prefix, tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestEnvTransformerRead(t *testing.T) {
+	fake := newFakeSSM("/app/db/host", "h", "/app/db/port", "5432")
+
+	mp, err := ProviderWithClient(Config{Path: "/app", Recursive: true}, EnvTransformer("/app"), fake).Read()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if mp["DB_HOST"] != "h" || mp["DB_PORT"] != "5432" {
+		t.Fatalf("got %v, want flat env keys", mp)
+	}
+}
+
+func TestPathTransformer(t *testing.T) {
+	for _, tc := range []struct {
+		prefix, delimiter, name, want string
+	}{
+		{"/app", ".", "/app/db/host", "db.host"},
+		{"/app", "::", "/app/db/host", "db::host"},
+		{"/app", ".", "/apple/x", "apple.x"},
+	} {
+		if got := PathTransformer(tc.prefix, tc.delimiter)(tc.name); got != tc.want {
+			t.Errorf("PathTransformer(%q, %q)(%q) = %q, want %q", tc.prefix, tc.delimiter, tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestMultiCharacterDelimiter(t *testing.T) {
+	fake := newFakeSSM("/app/db/host", "h", "/app/db/a::b", "v")
+
+	// Keep the leading delimiter for Read to trim
+	cb := func(name string) string {
+		return strings.ReplaceAll(name, "/", "::")
+	}
+
+	for name, tc := range map[string]struct {
+		cfg  Config
+		cb   func(string) string
+		want map[string]interface{}
+	}{
+		"leading": {
+			cfg:  Config{DelimiterConflict: DelimiterConflictReplace},
+			cb:   cb,
+			want: map[string]interface{}{"app": map[string]interface{}{"db": map[string]interface{}{"host": "h", "a_b": "v"}}},
+		},
+		"prefix": {
+			cfg:  Config{DelimiterConflict: DelimiterConflictReplace},
+			cb:   PathTransformer("/app", "::"),
+			want: map[string]interface{}{"db": map[string]interface{}{"host": "h", "a_b": "v"}},
+		},
+		"strip": {
+			cfg:  Config{DelimiterConflict: DelimiterConflictReplace, StripSegments: 1},
+			cb:   cb,
+			want: map[string]interface{}{"db": map[string]interface{}{"host": "h", "a_b": "v"}},
+		},
+		"normalize": {
+			cfg: Config{DelimiterConflict: DelimiterConflictReplace, NormalizeDelimiters: true},
+			cb: func(name string) string {
+				return strings.ReplaceAll(cb(name), "::db", "::::db::")
+			},
+			want: map[string]interface{}{"app": map[string]interface{}{"db": map[string]interface{}{"host": "h", "a_b": "v"}}},
+		},
+		"nest": {
+			cfg:  Config{DelimiterConflict: DelimiterConflictReplace, NestUnderPath: true},
+			cb:   PathTransformer("/app", "::"),
+			want: map[string]interface{}{"app": map[string]interface{}{"db": map[string]interface{}{"host": "h", "a_b": "v"}}},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			cfg := tc.cfg
+			cfg.Path = "/app"
+			cfg.Recursive = true
+			cfg.Delimiter = "::"
+
+			mp, err := ProviderWithClient(cfg, tc.cb, fake).Read()
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(mp, tc.want) {
+				t.Fatalf("got %v, want %v", mp, tc.want)
+			}
+		})
+	}
+}
+
+func TestKeepLeadingDelimiter(t *testing.T) {
+	fake := newFakeSSM("/app/db/host", "h")
+	nested := map[string]interface{}{"app": map[string]interface{}{"db": map[string]interface{}{"host": "h"}}}
+
+	for keep, want := range map[bool]map[string]interface{}{
+		false: nested,
+		true:  {"": nested},
+	} {
+		mp, err := ProviderWithClient(Config{Path: "/app", Recursive: true, KeepLeadingDelimiter: keep}, nil, fake).Read()
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(mp, want) {
+			t.Errorf("KeepLeadingDelimiter %t: got %v, want %v", keep, mp, want)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package paramstore
+
+import (
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+)
+
+// CallLatency describes a single GetParametersByPath call, as passed to
+// Config.ObserveCall.
+type CallLatency struct {
+	// Duration is the time the call took, including all retry attempts.
+	Duration time.Duration
+	// Attempts is the number of attempts the SDK made, or 0 if unknown,
+	// e.g. for failed calls and clients without the SDK retry middleware.
+	Attempts int
+	// Retried reports whether the call needed more than one attempt.
+	Retried bool
+	Err     error
+}
+
+// observeCall reports a GetParametersByPath call started at start to
+// cfg.ObserveCall.
+func observeCall(cfg Config, start time.Time, result *ssm.GetParametersByPathOutput, err error) {
+	if cfg.ObserveCall == nil {
+		return
+	}
+
+	call := CallLatency{
+		Duration: time.Since(start),
+		Err:      err,
+	}
+
+	if result != nil {
+		if results, ok := retry.GetAttemptResults(result.ResultMetadata); ok {
+			call.Attempts = len(results.Results)
+			call.Retried = call.Attempts > 1
+		}
+	}
+
+	cfg.ObserveCall(call)
+}
@@ -0,0 +1,61 @@
+package paramstore
+
+import (
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+// Leaf is a config value together with the metadata of the parameter it was
+// read from, as returned with LeafMetadata.
+type Leaf struct {
+	Value        interface{}
+	Version      int64
+	Type         types.ParameterType
+	LastModified time.Time
+}
+
+// withLeaves returns a copy of flat with the values read from SSM wrapped in
+// a Leaf.
+func withLeaves(flat map[string]interface{}, sources map[string]types.Parameter) map[string]interface{} {
+	out := make(map[string]interface{}, len(flat))
+
+	for key, value := range flat {
+		param, ok := sources[key]
+
+		if !ok {
+			out[key] = value
+
+			continue
+		}
+
+		out[key] = Leaf{
+			Value:        value,
+			Version:      param.Version,
+			Type:         param.Type,
+			LastModified: aws.ToTime(param.LastModifiedDate),
+		}
+	}
+
+	return out
+}
+
+// LeafValues returns a copy of the config m with every Leaf replaced by its
+// value, at any depth.
+func LeafValues(m map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(m))
+
+	for key, value := range m {
+		switch v := value.(type) {
+		case Leaf:
+			out[key] = v.Value
+		case map[string]interface{}:
+			out[key] = LeafValues(v)
+		default:
+			out[key] = value
+		}
+	}
+
+	return out
+}
@@ -0,0 +1,228 @@
+package paramstore
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+// ParamMeta holds the metadata of a single parameter version.
+//
+// Metadata fills it from the same GetParametersByPath responses Read uses, so
+// it costs no extra calls. Those responses carry Name, ARN, Type, DataType,
+// Version and LastModified; the remaining fields are only known to other
+// APIs, as noted on each.
+type ParamMeta struct {
+	Name         string
+	ARN          string
+	Type         types.ParameterType
+	DataType     string
+	Version      int64
+	LastModified time.Time
+	// LastModifiedUser, KeyID, Tier and Labels are only filled in by
+	// GetParameterHistory.
+	LastModifiedUser string
+	KeyID            string
+	Tier             types.ParameterTier
+	Labels           []string
+	// Description and AllowedPattern are only filled in for Metadata when
+	// FetchDescriptions or ValidateAllowedPatterns is enabled, since they
+	// require a DescribeParameters call.
+	Description    string
+	AllowedPattern string
+}
+
+// Metadata returns the metadata of the parameters fetched by the last Read,
+// without calling SSM again.
+func (ps *ParamStore) Metadata() []ParamMeta {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	return buildMetadata(ps.params, ps.described)
+}
+
+func buildMetadata(params []types.Parameter, described map[string]types.ParameterMetadata) []ParamMeta {
+	metas := make([]ParamMeta, 0, len(params))
+
+	for _, param := range params {
+		meta := paramMeta(param)
+		meta.Description = aws.ToString(described[meta.Name].Description)
+		meta.AllowedPattern = aws.ToString(described[meta.Name].AllowedPattern)
+		metas = append(metas, meta)
+	}
+
+	return metas
+}
+
+// Count returns the number of parameters fetched by the last Read.
+func (ps *ParamStore) Count() int {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	return len(ps.params)
+}
+
+// paramMeta extracts the metadata GetParametersByPath returns for a parameter.
+func paramMeta(param types.Parameter) ParamMeta {
+	return ParamMeta{
+		Name:         aws.ToString(param.Name),
+		ARN:          aws.ToString(param.ARN),
+		Type:         param.Type,
+		DataType:     aws.ToString(param.DataType),
+		Version:      param.Version,
+		LastModified: aws.ToTime(param.LastModifiedDate),
+	}
+}
+
+// describeParameters lists the metadata of every parameter below cfg.Path via
+// DescribeParameters, honoring the recursion setting and the configured
+// parameter filters.
+func describeParameters(ctx context.Context, cfg Config, client SSMClient) ([]types.ParameterMetadata, error) {
+	option := "OneLevel"
+
+	if cfg.Recursive {
+		option = "Recursive"
+	}
+
+	filters := []types.ParameterStringFilter{{
+		Key:    aws.String("Path"),
+		Option: aws.String(option),
+		Values: []string{cfg.Path},
+	}}
+
+	// Narrow the names server-side
+	if cfg.NameBeginsWith != "" {
+		filters = append(filters, types.ParameterStringFilter{
+			Key:    aws.String("Name"),
+			Option: aws.String("BeginsWith"),
+			Values: []string{cfg.NameBeginsWith},
+		})
+	}
+
+	// Label filters are only supported by GetParametersByPath
+	for _, f := range cfg.ParameterFilters {
+		if aws.ToString(f.Key) != "Label" {
+			filters = append(filters, f)
+		}
+	}
+
+	input := ssm.DescribeParametersInput{
+		ParameterFilters: filters,
+		MaxResults:       pageSize(cfg.DescribePageSize, describeParametersMaxResults),
+	}
+
+	var metas []types.ParameterMetadata
+
+	for {
+		result, err := client.DescribeParameters(ctx, &input)
+
+		if err != nil {
+			return nil, err
+		}
+
+		metas = append(metas, result.Parameters...)
+
+		input.NextToken = result.NextToken
+
+		if result.NextToken == nil {
+			break
+		}
+	}
+
+	return metas, nil
+}
+
+// GetParameterHistory returns up to limit of the most recent versions of the
+// named parameter, newest first. A limit of zero or less returns all versions.
+func (ps *ParamStore) GetParameterHistory(ctx context.Context, name string, limit int) ([]ParamMeta, error) {
+	cfg, client, err := ps.snapshot()
+
+	if err != nil {
+		return nil, err
+	}
+
+	input := ssm.GetParameterHistoryInput{
+		Name:       aws.String(name),
+		MaxResults: pageSize(cfg.DescribePageSize, describeParametersMaxResults),
+	}
+
+	// The API returns versions oldest first, so all pages have to be read
+	var history []types.ParameterHistory
+
+	for {
+		result, err := client.GetParameterHistory(ctx, &input)
+
+		if err != nil {
+			return nil, err
+		}
+
+		history = append(history, result.Parameters...)
+
+		input.NextToken = result.NextToken
+
+		if result.NextToken == nil {
+			break
+		}
+	}
+
+	if limit <= 0 || limit > len(history) {
+		limit = len(history)
+	}
+
+	metas := make([]ParamMeta, 0, limit)
+
+	for i := len(history) - 1; i >= len(history)-limit; i-- {
+		h := history[i]
+
+		metas = append(metas, ParamMeta{
+			Name:             aws.ToString(h.Name),
+			Type:             h.Type,
+			DataType:         aws.ToString(h.DataType),
+			Version:          h.Version,
+			LastModified:     aws.ToTime(h.LastModifiedDate),
+			LastModifiedUser: aws.ToString(h.LastModifiedUser),
+			KeyID:            aws.ToString(h.KeyId),
+			Tier:             h.Tier,
+			Labels:           h.Labels,
+			Description:      aws.ToString(h.Description),
+			AllowedPattern:   aws.ToString(h.AllowedPattern),
+		})
+	}
+
+	return metas, nil
+}
+
+// validatePatterns checks the values of params against the allowed patterns
+// in described. SecureStrings are skipped unless they were decrypted.
+func validatePatterns(ctx context.Context, params []types.Parameter, described map[string]types.ParameterMetadata) error {
+	var encrypted map[string]bool
+
+	if stats := statsFrom(ctx); stats != nil {
+		encrypted = stats.encrypted
+	}
+
+	for _, param := range params {
+		pattern := aws.ToString(described[aws.ToString(param.Name)].AllowedPattern)
+
+		if pattern == "" || encrypted[aws.ToString(param.Name)] {
+			continue
+		}
+
+		re, err := regexp.Compile(pattern)
+
+		if err != nil {
+			return fmt.Errorf("parameter %s has an invalid allowed pattern %q: %w", aws.ToString(param.Name), pattern, err)
+		}
+
+		if !re.MatchString(aws.ToString(param.Value)) {
+			return fmt.Errorf("value of parameter %s doesn't match its allowed pattern %q", aws.ToString(param.Name), pattern)
+		}
+	}
+
+	return nil
+}
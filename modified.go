@@ -0,0 +1,86 @@
+package paramstore
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+// ReadModifiedSince returns only the parameters below Path modified after
+// since, along with the latest modification time observed, which callers can
+// use as the next watermark. If nothing changed, the returned time is since.
+// Modified names are found via DescribeParameters, which can't filter by
+// date server-side, and their values fetched with GetParameters. Defaults
+// aren't applied and the read baseline isn't updated.
+func (ps *ParamStore) ReadModifiedSince(ctx context.Context, since time.Time) (map[string]interface{}, time.Time, error) {
+	cfg, client, err := ps.snapshot()
+
+	if err != nil {
+		return nil, since, err
+	}
+
+	if err := validate(cfg); err != nil {
+		return nil, since, err
+	}
+
+	metas, err := describeParameters(ctx, cfg, client)
+
+	if err != nil {
+		return nil, since, err
+	}
+
+	watermark := since
+
+	var names []string
+
+	for _, meta := range metas {
+		modified := aws.ToTime(meta.LastModifiedDate)
+
+		if !modified.After(since) {
+			continue
+		}
+
+		names = append(names, aws.ToString(meta.Name))
+
+		if modified.After(watermark) {
+			watermark = modified
+		}
+	}
+
+	// Keep the transformer order deterministic
+	sort.Strings(names)
+
+	params, err := getParameters(ctx, cfg, client, names, cfg.WithDecryption)
+
+	if err != nil {
+		return nil, since, err
+	}
+
+	mp, err := ps.transformAll(cfg, params)
+
+	if err != nil {
+		return nil, since, err
+	}
+
+	return unflatten(cfg, mp), watermark, nil
+}
+
+// transformAll transforms params into a flat map without applying defaults.
+func (ps *ParamStore) transformAll(cfg Config, params []types.Parameter) (map[string]interface{}, error) {
+	mp := make(map[string]interface{}, len(params))
+
+	for _, param := range params {
+		key, value, err := ps.transform(cfg, param)
+
+		if err != nil {
+			return nil, err
+		}
+
+		mp[key] = value
+	}
+
+	return mp, nil
+}
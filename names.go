@@ -0,0 +1,119 @@
+package paramstore
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+// Namespaces returns the sorted, distinct first-level segments below Path,
+// e.g. db, cache and features for /app/db/host, /app/cache/ttl and
+// /app/features/beta below /app. The whole tree is described regardless of
+// Recursive; no values are read.
+func (ps *ParamStore) Namespaces(ctx context.Context) ([]string, error) {
+	cfg, client, err := ps.snapshot()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.Path == "" {
+		return nil, errors.New("no parameter path provided")
+	}
+
+	cfg.Recursive = true
+
+	metas, err := describeParameters(ctx, cfg, client)
+
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := strings.TrimSuffix(cfg.Path, "/") + "/"
+	seen := make(map[string]bool)
+	var namespaces []string
+
+	for _, meta := range metas {
+		rest, ok := strings.CutPrefix(aws.ToString(meta.Name), prefix)
+
+		if !ok || rest == "" {
+			continue
+		}
+
+		segment, _, _ := strings.Cut(rest, "/")
+
+		if !seen[segment] {
+			seen[segment] = true
+			namespaces = append(namespaces, segment)
+		}
+	}
+
+	sort.Strings(namespaces)
+
+	return namespaces, nil
+}
+
+// Keys returns the sorted, distinct keys Read would produce for the
+// parameters below Path, without reading any values. Names are listed with
+// DescribeParameters and passed through the same transformer chain, so
+// nothing is decrypted. The transformer is called with an empty value and
+// ExpectedTypes are not checked. Keys selected by ExpandDotenv are returned
+// as they are, since the keys nested below them are only known from the
+// value.
+func (ps *ParamStore) Keys(ctx context.Context) ([]string, error) {
+	cfg, client, err := ps.snapshot()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.Path == "" {
+		return nil, errors.New("no parameter path provided")
+	}
+
+	metas, err := describeParameters(ctx, cfg, client)
+
+	if err != nil {
+		return nil, err
+	}
+
+	params := make([]types.Parameter, 0, len(metas))
+
+	for _, meta := range metas {
+		params = append(params, types.Parameter{
+			Name:  meta.Name,
+			Type:  meta.Type,
+			Value: aws.String(""),
+		})
+	}
+
+	// Only the keys matter here
+	cfg.ExpectedTypes = nil
+	cfg.ExpectedDataTypes = nil
+	cfg.TypeHandlers = nil
+
+	seen := make(map[string]bool)
+	keys := make([]string, 0, len(params))
+
+	for _, param := range filterDepth(params, cfg) {
+		key, _, err := ps.transform(cfg, param)
+
+		if err != nil {
+			return nil, err
+		}
+
+		// Parameters mapping to the same key yield it once, as in Read
+		if !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Strings(keys)
+
+	return keys, nil
+}
@@ -0,0 +1,93 @@
+package paramstore
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+func TestKeysIgnoresTypeHandlers(t *testing.T) {
+	fake := newFakeSSM("/app/a", "1")
+	fake.set("/app/list", "x,y", types.ParameterTypeStringList)
+
+	reject := func(s string) (interface{}, error) {
+		if s == "" {
+			return nil, errors.New("empty value")
+		}
+
+		return s, nil
+	}
+
+	ps := ProviderWithClient(Config{Path: "/app", TypeHandlers: map[types.ParameterType]func(string) (interface{}, error){
+		types.ParameterTypeString:     reject,
+		types.ParameterTypeStringList: reject,
+	}}, nil, fake)
+
+	keys, err := ps.Keys(context.Background())
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"app/a", "app/list"}; !reflect.DeepEqual(keys, want) {
+		t.Fatalf("got %v, want %v", keys, want)
+	}
+}
+
+func TestNameBeginsWith(t *testing.T) {
+	fake := newFakeSSM("/app/db/primary", "p", "/app/db/replica", "r", "/app/name", "svc")
+	ps := ProviderWithClient(Config{Path: "/app", Recursive: true, NameBeginsWith: "/app/db/pri"}, nil, fake)
+
+	// Path reads filter the results
+	mp, err := ps.Read()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]interface{}{"app": map[string]interface{}{"db": map[string]interface{}{"primary": "p"}}}
+
+	if !reflect.DeepEqual(mp, want) {
+		t.Fatalf("got %v, want %v", mp, want)
+	}
+
+	// DescribeParameters gets a server-side filter
+	if _, err := ps.Keys(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	var found bool
+
+	for _, filter := range fake.describes[len(fake.describes)-1].ParameterFilters {
+		if aws.ToString(filter.Key) == "Name" && aws.ToString(filter.Option) == "BeginsWith" && reflect.DeepEqual(filter.Values, []string{"/app/db/pri"}) {
+			found = true
+		}
+	}
+
+	if !found {
+		t.Fatalf("got filters %+v, want a Name BeginsWith filter", fake.describes[len(fake.describes)-1].ParameterFilters)
+	}
+}
+
+func TestKeysAreDistinct(t *testing.T) {
+	fake := newFakeSSM("/app/DB", "1", "/app/db", "2", "/app/a", "3")
+
+	ps := ProviderWithClient(Config{Path: "/app"}, func(name string) string {
+		return strings.ToLower(strings.TrimPrefix(name, "/app/"))
+	}, fake)
+
+	keys, err := ps.Keys(context.Background())
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"a", "db"}; !reflect.DeepEqual(keys, want) {
+		t.Fatalf("got %v, want %v", keys, want)
+	}
+}
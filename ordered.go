@@ -0,0 +1,79 @@
+package paramstore
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// KeyValue is a single flat config entry.
+type KeyValue struct {
+	Key   string
+	Value interface{}
+}
+
+// ReadOrdered reads like Read, but returns the flat config as a slice sorted
+// by key, for deterministic iteration. Keys are delimiter-joined and values
+// are exactly those of Result.Flat, so secrets stay wrapped.
+func (ps *ParamStore) ReadOrdered(ctx context.Context) ([]KeyValue, error) {
+	res, _, err := ps.read(ctx, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]KeyValue, 0, len(res.Flat))
+
+	for key, value := range res.Flat {
+		entries = append(entries, KeyValue{Key: key, Value: value})
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Key < entries[j].Key
+	})
+
+	return entries, nil
+}
+
+// ReadFlatSorted reads like Read and returns one "key=value" line per flat
+// key, sorted by key, for committing and diffing config snapshots.
+// SecureString values are "[REDACTED]" unless includeSecrets is set. Values
+// are formatted like Import writes them, and quoted Go-style if they span
+// lines, so every entry stays on one line.
+func (ps *ParamStore) ReadFlatSorted(ctx context.Context, includeSecrets bool) ([]string, error) {
+	res, _, err := ps.read(ctx, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	values, err := stringifyValues(exportValues(res, includeSecrets))
+
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(values))
+
+	for key := range values {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	lines := make([]string, 0, len(keys))
+
+	for _, key := range keys {
+		str := fmt.Sprint(values[key])
+
+		if strings.ContainsAny(str, "\r\n") {
+			str = strconv.Quote(str)
+		}
+
+		lines = append(lines, key+"="+str)
+	}
+
+	return lines, nil
+}
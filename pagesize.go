@@ -0,0 +1,22 @@
+package paramstore
+
+import "github.com/aws/aws-sdk-go-v2/aws"
+
+const (
+	// getParametersByPathMaxResults is the largest page GetParametersByPath
+	// returns
+	getParametersByPathMaxResults = 10
+	// describeParametersMaxResults is the largest page DescribeParameters
+	// and GetParameterHistory return
+	describeParametersMaxResults = 50
+)
+
+// pageSize clamps the configured page size n to [1, limit], using limit when
+// n is unset.
+func pageSize(n, limit int) *int32 {
+	if n <= 0 || n > limit {
+		n = limit
+	}
+
+	return aws.Int32(int32(n))
+}
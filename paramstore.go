@@ -2,17 +2,20 @@ package paramstore
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
-	"github.com/aws/aws-sdk-go-v2/credentials"
-	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
 	"github.com/aws/aws-sdk-go-v2/service/ssm"
 	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
-	"github.com/aws/aws-sdk-go-v2/service/sts"
 	"github.com/knadh/koanf/maps"
+	"github.com/knadh/koanf/v2"
+	"golang.org/x/time/rate"
 )
 
 type Config struct {
@@ -25,54 +28,478 @@ type Config struct {
 	AWSRoleARN         string
 	AWSRegion          string
 	WatchInterval      time.Duration
+	// CredentialPrecedence picks between static keys and AWSRoleARN when
+	// both are set. See CredentialPrecedenceRole for the default.
+	CredentialPrecedence CredentialPrecedence
+	// Logger receives warnings and diagnostics. Nil disables logging.
+	Logger Logger
+	// Recursive reads the whole tree below Path instead of only its direct
+	// children.
+	Recursive bool
+	// MaxDepth limits a recursive read to parameters at most this many
+	// segments below Path. Zero means unlimited.
+	MaxDepth int
+	// DiscoverTags finds additional parameters by tag (key to accepted
+	// values) via DescribeParameters and merges them into the result.
+	// Parameters that are also below Path are only included once, as read
+	// from Path. Path may be left empty to read by tags only.
+	DiscoverTags map[string][]string
+	// ReadProgress is called after every fetched page with the running
+	// parameter count.
+	ReadProgress func(fetched int)
+	// TrimSpace strips leading and trailing whitespace from values before
+	// they reach the transformer. Values are otherwise passed on byte for
+	// byte, so multiline values such as PEM certificates keep their line
+	// breaks; note that TrimSpace also drops their trailing newline.
+	TrimSpace bool
+	// WatchEmitInitial makes Watch call its callback once on start with the
+	// current baseline parameters.
+	WatchEmitInitial bool
+	// KeyCase normalizes the case of transformed keys. See KeyCaseNone.
+	KeyCase KeyCase
+	// FetchDescriptions makes Read issue an extra DescribeParameters call to
+	// populate ParamMeta.Description and ParamMeta.AllowedPattern in
+	// Metadata.
+	FetchDescriptions bool
+	// Defaults holds flat, delimiter-joined keys that are used when the
+	// corresponding key is absent from SSM. SSM values always win.
+	Defaults map[string]interface{}
+	// DelimiterConflict controls how a custom Delimiter appearing inside an
+	// SSM name segment is handled. See DelimiterConflictIgnore.
+	DelimiterConflict DelimiterConflict
+	// DelimiterReplacement substitutes the delimiter inside name segments
+	// when DelimiterConflict is DelimiterConflictReplace. Defaults to "_".
+	DelimiterReplacement string
+	// TolerateDecryptionErrors keeps reading when some SecureStrings can't
+	// be decrypted. Those parameters are skipped, reported via Logger and
+	// listed by DecryptionFailures.
+	TolerateDecryptionErrors bool
+	// SSMEndpointURL and STSEndpointURL override the endpoints of the
+	// respective clients, e.g. for separate PrivateLink interface endpoints
+	// like "https://vpce-0123-abcd.ssm.eu-west-1.vpce.amazonaws.com".
+	// Requests are still signed with SigV4 for the "ssm" and "sts" services
+	// in the client's region, which is never derived from the hostname, so
+	// the region has to match the endpoint's. Empty values use the standard
+	// endpoint resolution.
+	SSMEndpointURL string
+	STSEndpointURL string
+	// IsRetryable adds errors the SDK retryer retries on top of its
+	// built-in classification (throttling, 5xx, transient network errors).
+	// Errors it returns false for are still classified by the defaults.
+	IsRetryable func(error) bool
+	// Debug dumps every GetParametersByPath page to Logger. SecureString
+	// values are always redacted.
+	Debug bool
+	// WrapSecrets stores SecureString values as SecretString instead of
+	// string.
+	WrapSecrets bool
+	// MaxPages aborts a read with ErrMaxPagesExceeded once more than this
+	// many GetParametersByPath pages would be needed. Zero means unlimited.
+	MaxPages int
+	// TolerateAccessDenied turns AccessDenied errors on a path into a partial
+	// read: denied branches are skipped and logged, and everything readable
+	// is returned. Read then no longer guarantees a complete config.
+	TolerateAccessDenied bool
+	// Lazy defers loading the AWS config and building the client until the
+	// provider is first used, so an unused provider has no AWS side effects.
+	Lazy bool
+	// UsePathPrefixOnWrite treats names passed to the write methods as
+	// relative to Path, so "db/host" is written to "<Path>/db/host".
+	UsePathPrefixOnWrite bool
+	// Parser serializes the config in ReadBytes. It should be the parser
+	// passed to koanf's Load. Defaults to JSON.
+	Parser Parser
+	// RetryMode selects the SDK retry mode. aws.RetryModeAdaptive adds
+	// client-side rate limiting on throttling. Defaults to
+	// aws.RetryModeStandard.
+	RetryMode aws.RetryMode
+	// Transformers are applied to every key in order, after the transformer
+	// passed to the constructor and before KeyCase.
+	Transformers []func(string) string
+	// ExpectedTypes declares the type of transformed keys. Read converts
+	// their values and fails if a value doesn't parse. Supported types are
+	// TypeString, TypeInt, TypeFloat, TypeBool, TypeDuration and TypeStrings.
+	ExpectedTypes map[string]string
+	// Tracer creates spans around reads ("paramstore.Read") and watch ticks
+	// ("paramstore.Watch.tick"). Nil disables tracing.
+	Tracer Tracer
+	// ResolveReferences substitutes {{resolve:ssm:/name}} references in
+	// values with the referenced parameter's value, following nested
+	// references up to 10 levels and failing on cycles.
+	ResolveReferences bool
+	// WatchKeys limits Watch to changes of these transformed keys. The full
+	// path is still fetched on every tick.
+	WatchKeys []string
+	// WatchCallbackTimeout runs the Watch callback in its own goroutine and
+	// stops waiting for it after this long, logging a warning, so a slow
+	// callback can't stall change detection. Abandoned callbacks keep
+	// running. Zero calls the callback synchronously, during which ticks
+	// are dropped.
+	WatchCallbackTimeout time.Duration
+	// AutoDecryptSecureStrings treats the read as a probe when
+	// WithDecryption is false: if it returns any SecureStrings, the read is
+	// repeated with decryption, and so are later watch ticks.
+	AutoDecryptSecureStrings bool
+	// CollectErrors makes Read report the errors of all broken parameters
+	// joined together instead of stopping at the first one.
+	CollectErrors bool
+	// TreatEmptyAsMissing leaves parameters with an empty value out of the
+	// result, as if they didn't exist, so Defaults apply to them. SSM
+	// rejects empty values, so this mostly matters together with TrimSpace
+	// for whitespace-only values.
+	TreatEmptyAsMissing bool
+	// ConfigOptions are passed to config.LoadDefaultConfig after the built-in
+	// retry options, so they override RetryMode and IsRetryable. AWSRegion,
+	// the static keys, AWSRoleARN and the endpoint URLs are applied after
+	// loading and still take precedence over them. Changing ConfigOptions
+	// through UpdateConfig doesn't rebuild an existing client.
+	ConfigOptions []func(*config.LoadOptions) error
+	// RetryJitter selects how retry delays are randomized. It is an enum
+	// rather than a bool so the zero value can mean full jitter, the safe
+	// default for many instances retrying at once: RetryJitterFull ("") waits
+	// a random delay up to the exponential backoff, RetryJitterNone ("none")
+	// waits the backoff itself. Other values fail every read.
+	RetryJitter RetryJitter
+	// RetryMaxDelay caps the delay between retries. Defaults to the SDK's 20
+	// seconds.
+	RetryMaxDelay time.Duration
+	// ExpectedDataTypes declares the SSM data type ("text", "aws:ec2:image"
+	// or "aws:ssm:integration") of transformed keys. Read fails if a
+	// parameter has a different one. Parameters without a data type count
+	// as "text".
+	ExpectedDataTypes map[string]string
+	// WatchRefresh makes Watch re-read the full snapshot whenever it detects
+	// a change, before calling the callback, so LastRead is always current
+	// and later ticks compare against the new versions.
+	WatchRefresh bool
+	// KeepLeadingDelimiter keeps a leading Delimiter on transformed keys. By
+	// default it's trimmed, so "/app/db/host" with a "/" delimiter becomes
+	// {"app": {"db": {"host": ...}}} rather than being nested below an empty
+	// key.
+	KeepLeadingDelimiter bool
+	// BeforeRead is called before every read and watch tick. The context it
+	// returns is used for all SSM calls of that read, so middleware added
+	// through ConfigOptions can pick up per-read values from it. SSM doesn't
+	// accept KMS grant tokens when reading parameters; access granted through
+	// KMS grants has to be usable without them, e.g. via a grant for the
+	// caller's role. Returning an error aborts the read.
+	BeforeRead func(ctx context.Context) (context.Context, error)
+	// NameBeginsWith limits reads to parameters whose full name starts with
+	// the given string, e.g. "/app/db/pri". DescribeParameters based calls
+	// send it as a Name BeginsWith filter; GetParametersByPath doesn't
+	// support name filters, so path reads apply it to the results.
+	NameBeginsWith string
+	// SortBy orders the parameters before they are transformed and in
+	// Metadata. Defaults to SortByName.
+	SortBy SortBy
+	// SortDescending reverses the SortBy order.
+	SortDescending bool
+	// MaxConsecutiveWatchErrors stops Watch once a tick has failed with the
+	// same error this many times in a row. SSM errors count as the same if
+	// they have the same error code, e.g. AccessDeniedException. The
+	// callback then receives a final error wrapping ErrWatchTerminated and
+	// the last error. Zero keeps watching forever.
+	MaxConsecutiveWatchErrors int
+	// Retryer is used for all SSM and STS calls instead of a retryer built
+	// from RetryMode, IsRetryable, RetryJitter and RetryMaxDelay, which are
+	// ignored when it's set. ConfigOptions can still override it. Changing it
+	// through UpdateConfig doesn't rebuild an existing client.
+	Retryer aws.Retryer
+	// ExpandDotenv selects the transformed keys whose value is a .env style
+	// blob of KEY=VALUE lines. Each line becomes a key nested below the
+	// parameter's key, e.g. "app.env" holding "DB_HOST=x" yields
+	// "app.env.DB_HOST". The parameter's key itself is not set.
+	ExpandDotenv func(key string) bool
+	// WatchFetchTimeout bounds each watch tick, so a hung fetch fails the
+	// tick instead of blocking the watch. Zero means no timeout.
+	WatchFetchTimeout time.Duration
+	// NormalizeDelimiters cleans up transformed keys before they are
+	// unflattened: any "/" becomes Delimiter, repeated delimiters collapse
+	// into one and a trailing delimiter is dropped. With a "." delimiter,
+	// both "/app//db/host" and "app./db.host" become "app.db.host".
+	NormalizeDelimiters bool
+	// CacheDefaultConfig loads the AWS default config (environment, shared
+	// config and credential files) once per process and shares it between
+	// all providers with this option, instead of loading it for every
+	// provider. ResetDefaultConfigCache drops the cached copy. It has no
+	// effect when ConfigOptions are set.
+	CacheDefaultConfig bool
+	// RateLimit caps GetParametersByPath calls of Read and Watch at this many
+	// per second, with a burst of one.
+	RateLimit rate.Limit
+	// RateLimiter gates GetParametersByPath calls like RateLimit, but can be
+	// shared between providers to bound their combined rate. It takes
+	// precedence over RateLimit.
+	RateLimiter *rate.Limiter
+	// WatchDebounce holds back watch notifications until no new change has
+	// been detected for this long, then reports all changes since the last
+	// notification at once. Each parameter version is reported only once.
+	// Zero notifies on every tick with changes.
+	WatchDebounce time.Duration
+	// StrictDecryption makes Read fail if it finds SecureStrings while
+	// WithDecryption is off, instead of returning their encrypted values.
+	StrictDecryption bool
+	// Unflattener turns the flat, delimiter-joined keys into the nested
+	// config wherever one is returned, receiving Delimiter as delim. Defaults
+	// to maps.Unflatten.
+	Unflattener func(flat map[string]interface{}, delim string) map[string]interface{}
+	// MaxParameters aborts a read with ErrMaxParametersExceeded once more
+	// than this many parameters have been collected. Zero means unlimited.
+	MaxParameters int
+	// DecryptPredicate selects the SecureStrings to decrypt by name,
+	// overriding WithDecryption. Parameters are read without decryption and
+	// the selected ones fetched again, decrypted, via GetParameters, which
+	// costs one extra call per 10 selected names. Unselected SecureStrings
+	// keep their encrypted value.
+	DecryptPredicate func(name string) bool
+	// RenderTemplates renders string values containing "{{" with
+	// text/template after defaults have been applied. Templates see
+	// TemplateData as ".", e.g. "https://{{.region}}.example.com", and can
+	// use {{env "NAME"}} for the variables listed in TemplateEnv and
+	// {{key "db.host"}} for the rendered value of another key, nested at
+	// most 10 deep. {{resolve:ssm:/name}} references left unresolved are
+	// kept as they are. Missing data and cyclic key references fail the read
+	// with an error naming the key.
+	RenderTemplates bool
+	// TemplateData is the data RenderTemplates passes to templates as ".".
+	TemplateData map[string]interface{}
+	// TemplateEnv lists the environment variables templates may read with
+	// env. Others, and all of them when TemplateEnv is empty, are errors,
+	// so config values can't expose the process environment.
+	TemplateEnv []string
+	// PathPageSize is the MaxResults of GetParametersByPath calls, at most
+	// 10. DescribePageSize is the MaxResults of DescribeParameters and
+	// GetParameterHistory calls, at most 50. GetParameters takes up to 10
+	// names and has no page size. Unset or out of range values use the
+	// respective maximum, so fewer calls are needed.
+	PathPageSize     int
+	DescribePageSize int
+	// AWSRoleDuration is the session duration requested when assuming
+	// AWSRoleARN, between 15 minutes and 12 hours. It can't exceed the
+	// role's maximum session duration, which is 1 hour unless raised on the
+	// role, and role chaining limits it to 1 hour regardless. Defaults to
+	// 1 hour.
+	AWSRoleDuration time.Duration
+	// OperationTimeout bounds a whole read, across all pages, retries,
+	// follow-up calls and FallbackRegions, unlike the per-call timeouts of
+	// the SDK. A read that runs out of time fails with an error wrapping
+	// context.DeadlineExceeded.
+	OperationTimeout time.Duration
+	// StripSegments drops that many leading segments from every key after
+	// the transformers ran, e.g. 2 turns "/app/prod/db/host" into
+	// "db/host", regardless of Path. Keys left without a segment fail the
+	// read.
+	StripSegments int
+	// StrictPagination fails a read when GetParametersByPath returns the
+	// same ARN more than once, which points at a broken backend. By default
+	// duplicates are dropped and reported via Logger.
+	StrictPagination bool
+	// UserAgentSuffix is appended to the user agent of SSM requests, after
+	// the "koanf-paramstore/<version>" every request carries, so API calls
+	// can be attributed in CloudTrail. It doesn't apply to clients injected
+	// via ProviderWithClient.
+	UserAgentSuffix string
+	// LeafMetadata makes Read and ReadResult return a Leaf with the value
+	// and parameter metadata in place of every value read from SSM. Values
+	// filled in from Defaults stay bare. Use LeafValues to get the plain
+	// config, e.g. for koanf.
+	LeafMetadata bool
+	// DefaultKMSKeyID is the KMS key SecureStrings are written with when
+	// WriteOptions.KeyID is empty. Writes of other types never use it.
+	DefaultKMSKeyID string
+	// WatchHeartbeat makes Watch call its callback with an empty, non-nil
+	// []types.Parameter and no error on every successful tick that doesn't
+	// report changes, so consumers can tell a quiet watch from a dead one.
+	// No heartbeats are sent while WatchDebounce holds changes back.
+	WatchHeartbeat bool
+	// Credentials replaces the credentials of the default AWS config chain,
+	// e.g. for certificate based IAM Roles Anywhere sessions. Static keys
+	// still take precedence, and AWSRoleARN is assumed with these as the
+	// source credentials.
+	Credentials aws.CredentialsProvider
+	// Paths lists further paths read like Path, each with its own recursion
+	// and filters, and merged in after Path. Parameters found below several
+	// paths are only included once, from the first one. Path may be left
+	// empty. MaxDepth, FetchDescriptions and the describe based calls like
+	// Namespaces only consider Path.
+	Paths []PathSpec
+	// ObserveCall is called after every GetParametersByPath call with its
+	// latency and retry attempts, e.g. to track SSM latency percentiles.
+	ObserveCall func(call CallLatency)
+	// ValidateAllowedPatterns fails Read when a value doesn't match the
+	// AllowedPattern of its parameter. The patterns are fetched with an
+	// extra DescribeParameters call, which also fills in
+	// ParamMeta.AllowedPattern. Values of SecureStrings that stay encrypted,
+	// e.g. those DecryptPredicate doesn't select, aren't checked.
+	ValidateAllowedPatterns bool
+	// FallbackRegions are tried in order when a read in the primary region
+	// fails with a network error or a server side 5xx error. Replicating the
+	// parameters to those regions is up to the caller. Region reports the
+	// region that served the last read. Watch ticks only poll the primary
+	// region, and clients injected via ProviderWithClient never fall back.
+	FallbackRegions []string
+	// OnPage is called after every fetched GetParametersByPath page with
+	// its parameters, before they are filtered or transformed, and the page
+	// number, starting at 1 for every path read.
+	OnPage func(params []types.Parameter, page int)
+	// SimulateMaxPages stops reading a path after that many pages even if
+	// more exist, for measuring cost and latency on growing subsets.
+	// Unlike MaxPages, the partial result is returned as is, and
+	// WasTruncated reports it.
+	SimulateMaxPages int
+	// RegionResolver determines the region whenever the client is built,
+	// overriding AWSRegion. If it fails, so does building the client: Provider
+	// returns nil and lazy providers return the error from every read until
+	// it succeeds. It isn't used for FallbackRegions or clients injected via
+	// ProviderWithClient.
+	RegionResolver func() (string, error)
+	// NestUnderPath nests every key below the segments of Path, e.g. with
+	// Path "/app/prod" and a transformer that strips it, "db.host" becomes
+	// "app.prod.db.host", so several providers merge into one tree
+	// mirroring SSM. Keys already below the path are left alone. It can't
+	// be combined with StripSegments.
+	NestUnderPath bool
+	// WatchPrevious makes Watch events a []ParamChange carrying the previous
+	// value of every changed parameter next to the new one, instead of a
+	// []types.Parameter. SecureString values are redacted in them unless
+	// WatchRevealSecrets is set.
+	WatchPrevious      bool
+	WatchRevealSecrets bool
+	// RequireCredentials retrieves the credentials while building the
+	// client and fails if there are none or they are anonymous, instead of
+	// failing with AccessDenied on the first call. Provider returns nil in
+	// that case and lazy providers return the error from every read until
+	// credentials are available.
+	RequireCredentials bool
+	// TypeHandlers convert the values of parameters by SSM type, after the
+	// transformer and before WrapSecrets and ExpectedTypes, e.g.
+	// SplitStringList for types.ParameterTypeStringList. Types without a
+	// handler keep their string value, as do values the transformer already
+	// turned into something else. Read fails if a handler returns an error.
+	TypeHandlers map[types.ParameterType]func(string) (interface{}, error)
+	// ObserveWatch is called after every tick of Watch and WatchOne with the
+	// number of ticks failed in a row and whether the watch just recovered,
+	// e.g. to raise an alert while a watch is failing and clear it once it
+	// recovers. Ticks keep their WatchInterval while failing.
+	ObserveWatch func(health WatchHealth)
 }
 
+// PathSpec is a single source path of Config.Paths.
+type PathSpec struct {
+	Path      string
+	Recursive bool
+	// ParameterFilters defaults to Config.ParameterFilters when nil.
+	ParameterFilters []types.ParameterStringFilter
+}
+
+// PathSpecs turns paths into PathSpecs sharing the given recursion and the
+// global filters.
+func PathSpecs(recursive bool, paths ...string) []PathSpec {
+	specs := make([]PathSpec, len(paths))
+
+	for i, path := range paths {
+		specs[i] = PathSpec{Path: path, Recursive: recursive}
+	}
+
+	return specs
+}
+
+// Parser is the serialization half of a koanf parser. The JSON, YAML and
+// TOML parsers of both koanf v1 and v2 satisfy it.
+type Parser interface {
+	Unmarshal([]byte) (map[string]interface{}, error)
+	Marshal(map[string]interface{}) ([]byte, error)
+}
+
+// ErrMaxPagesExceeded is returned when a read needs more than MaxPages pages.
+var ErrMaxPagesExceeded = errors.New("maximum number of pages exceeded")
+
+// ErrMaxParametersExceeded is returned when a read collects more than
+// MaxParameters parameters.
+var ErrMaxParametersExceeded = errors.New("maximum number of parameters exceeded")
+
 type ParamStore struct {
-	client *ssm.Client
+	mu sync.RWMutex
+	// update serializes UpdateConfig, so a config is never paired with the
+	// client built for another one
+	update sync.Mutex
+	client SSMClient
 	config Config
 	input  ssm.GetParametersByPathInput
 	params []types.Parameter
-	cb     func(k, v string) (string, interface{})
+	flat   map[string]interface{}
+	// described maps parameter names to their DescribeParameters metadata
+	// when FetchDescriptions or ValidateAllowedPatterns is enabled
+	described map[string]types.ParameterMetadata
+	cb        func(k, v string) (string, interface{})
+	// ownsClient is false when the client was injected by the caller
+	ownsClient bool
+	// awsConfig is the AWS config the client was built from
+	awsConfig aws.Config
+	// pages is the number of GetParametersByPath pages of the last read
+	pages int
+	// changes is the diff between the last two reads
+	changes []Change
+	// decryptionFailures lists the SecureStrings skipped by the last read
+	decryptionFailures []string
+	// servedRegion is the fallback region that served the last read, if any
+	servedRegion string
+	// truncated is set when SimulateMaxPages cut the last read short
+	truncated bool
+	// warnings lists the non-fatal problems of the last read
+	warnings []string
+	// readAt is when the last read started
+	readAt time.Time
 }
 
+// ParamStore satisfies koanf.Provider. The interface has the same two methods
+// in koanf v1, so the provider loads into either version.
+var _ koanf.Provider = (*ParamStore)(nil)
+
 func Provider(cfg Config, cb func(k string) string) *ParamStore {
-	// Load the default config
-	c, err := config.LoadDefaultConfig(context.Background())
+	var vcb func(key, value string) (string, interface{})
 
-	if err != nil {
-		return nil
+	if cb != nil {
+		vcb = func(key, value string) (string, interface{}) {
+			return cb(key), value
+		}
 	}
 
-	// Initialize delimiter string
-	if cfg.Delimiter == "" {
-		cfg.Delimiter = "/"
-	}
+	return newProvider(cfg, vcb)
+}
 
-	// Initialize AWS region
-	if cfg.AWSRegion != "" {
-		c.Region = cfg.AWSRegion
-	}
+func ProviderWithValue(cfg Config, cb func(key string, value string) (string, interface{})) *ParamStore {
+	return newProvider(cfg, cb)
+}
+
+// newProvider builds a provider owning its client. Lazy providers defer
+// building the client until it is first needed.
+func newProvider(cfg Config, cb func(key, value string) (string, interface{})) *ParamStore {
+	if cfg.Lazy {
+		applyDefaults(&cfg)
 
-	// Initialize watch interval
-	if cfg.WatchInterval == 0 {
-		cfg.WatchInterval = 600 * time.Second
+		return &ParamStore{config: cfg, cb: cb, ownsClient: true}
 	}
 
-	// Check if AWS access key ID and secret key are specified
-	if cfg.AWSAccessKeyID != "" && cfg.AWSSecretAccessKey != "" {
-		c.Credentials = credentials.NewStaticCredentialsProvider(cfg.AWSAccessKeyID, cfg.AWSSecretAccessKey, "")
+	client, awsCfg, err := newClient(&cfg)
+
+	if err != nil {
+		return nil
 	}
 
-	// Check if AWS role ARN is present
-	if cfg.AWSRoleARN != "" {
-		stsSvc := sts.NewFromConfig(c)
-		credentials := stscreds.NewAssumeRoleProvider(stsSvc, cfg.AWSRoleARN)
-		c.Credentials = aws.NewCredentialsCache(credentials)
+	return &ParamStore{
+		client:     client,
+		awsConfig:  awsCfg,
+		config:     cfg,
+		cb:         cb,
+		ownsClient: true,
 	}
+}
 
-	client := ssm.NewFromConfig(c)
+func ProviderWithClient(cfg Config, cb func(s string) string, client SSMClient) *ParamStore {
+	applyDefaults(&cfg)
 
-	ps := &ParamStore{client: client, config: cfg}
+	ps := &ParamStore{client: client, config: cfg, awsConfig: aws.Config{Region: cfg.AWSRegion}}
 
 	if cb != nil {
 		ps.cb = func(key, value string) (string, interface{}) {
@@ -83,153 +510,1100 @@ func Provider(cfg Config, cb func(k string) string) *ParamStore {
 	return ps
 }
 
-func ProviderWithValue(cfg Config, cb func(key string, value string) (string, interface{})) *ParamStore {
-	// Load the default config
-	c, err := config.LoadDefaultConfig(context.Background())
+// UpdateConfig replaces the provider's config at runtime. The SSM client is
+// rebuilt when credentials or region change, unless it was injected via
+// ProviderWithClient, in which case the injected client is kept. Concurrent
+// calls are applied one at a time.
+func (ps *ParamStore) UpdateConfig(cfg Config) error {
+	ps.update.Lock()
+	defer ps.update.Unlock()
 
-	if err != nil {
+	applyDefaults(&cfg)
+
+	if err := validate(cfg); err != nil {
+		return err
+	}
+
+	ps.mu.RLock()
+	old := ps.config
+	ownsClient := ps.ownsClient
+	initialized := ps.client != nil
+	ps.mu.RUnlock()
+
+	var client SSMClient
+	var awsCfg aws.Config
+
+	// Rebuild the client outside of the lock. Lazy providers that haven't
+	// been used yet will pick up the new config on first use.
+	if ownsClient && initialized && clientConfigChanged(old, cfg) {
+		c, a, err := newClient(&cfg)
+
+		if err != nil {
+			return err
+		}
+
+		client = c
+		awsCfg = a
+	}
+
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	ps.config = cfg
+
+	if client != nil {
+		ps.client = client
+		ps.awsConfig = awsCfg
+	}
+
+	// A lazy client built from the old config in the meantime is dropped, so
+	// the next use builds it from the new one
+	if ownsClient && !initialized && ps.client != nil && clientConfigChanged(old, cfg) {
+		ps.client = nil
+		ps.awsConfig = aws.Config{}
+	}
+
+	// Injected clients only know the configured region
+	if !ownsClient {
+		ps.awsConfig = aws.Config{Region: cfg.AWSRegion}
+	}
+
+	return nil
+}
+
+// Reset drops everything remembered from earlier reads: the baseline used by
+// Watch, ReadSince and the diffs, the metadata, the flat config and the
+// stored input. The client and config are kept. The next Read or Watch starts
+// from scratch; a Watch already running reports all parameters as new on its
+// next tick.
+func (ps *ParamStore) Reset() {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	ps.input = ssm.GetParametersByPathInput{}
+	ps.params = nil
+	ps.flat = nil
+	ps.described = nil
+	ps.pages = 0
+	ps.changes = nil
+	ps.decryptionFailures = nil
+	ps.truncated = false
+	ps.warnings = nil
+	ps.servedRegion = ""
+	ps.readAt = time.Time{}
+}
+
+// LastPageCount returns how many GetParametersByPath pages the last
+// successful read needed, including the re-reads of tolerant and automatic
+// decryption.
+func (ps *ParamStore) LastPageCount() int {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	return ps.pages
+}
+
+// WasTruncated reports whether SimulateMaxPages cut the last successful read
+// short.
+func (ps *ParamStore) WasTruncated() bool {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	return ps.truncated
+}
+
+// LastRead returns the config of the most recent successful read, including
+// the re-reads of Watch with WatchRefresh, without calling SSM. It returns nil
+// if nothing has been read yet.
+func (ps *ParamStore) LastRead() map[string]interface{} {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	if ps.flat == nil {
 		return nil
 	}
 
-	// Initialize delimiter string
-	if cfg.Delimiter == "" {
-		cfg.Delimiter = "/"
+	return unflatten(ps.config, ps.flat)
+}
+
+// Region returns the AWS region the SSM client uses, as resolved from
+// AWSRegion, the environment or the shared config, or the fallback region
+// that served the last read. For clients injected via ProviderWithClient it
+// is AWSRegion, which may be empty. Lazy providers build
+// their client on the first call; an empty string is returned if that fails.
+func (ps *ParamStore) Region() string {
+	if _, _, err := ps.snapshot(); err != nil {
+		return ""
 	}
 
-	// Initialize AWS region
-	if cfg.AWSRegion != "" {
-		c.Region = cfg.AWSRegion
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	if ps.servedRegion != "" {
+		return ps.servedRegion
 	}
 
-	// Initialize watch interval
-	if cfg.WatchInterval == 0 {
-		cfg.WatchInterval = 600 * time.Second
+	return ps.awsConfig.Region
+}
+
+func (ps *ParamStore) Read() (map[string]interface{}, error) {
+	return ps.ReadWithContext(context.Background())
+}
+
+// read fetches all parameters, transforms them into a flat map and stores
+// the result as the new baseline. If filter is set, it may drop fetched
+// parameters before they are transformed. The returned Result has no Map or
+// Checksum; callers fill those in when needed.
+func (ps *ParamStore) read(ctx context.Context, filter func([]types.Parameter) []types.Parameter) (*Result, Config, error) {
+	// Take a consistent snapshot of config and client
+	cfg, client, err := ps.snapshot()
+
+	if err != nil {
+		return nil, cfg, err
 	}
 
-	// Check if AWS access key ID and secret key are specified
-	if cfg.AWSAccessKeyID != "" && cfg.AWSSecretAccessKey != "" {
-		c.Credentials = credentials.NewStaticCredentialsProvider(cfg.AWSAccessKeyID, cfg.AWSSecretAccessKey, "")
+	return ps.readFrom(ctx, cfg, client, filter)
+}
+
+// readFrom implements read for a config and client snapshot, falling back to
+// cfg.FallbackRegions if needed.
+func (ps *ParamStore) readFrom(ctx context.Context, cfg Config, client SSMClient, filter func([]types.Parameter) []types.Parameter) (*Result, Config, error) {
+	// Bound the whole read, including the fallbacks
+	ctx, cancel := operationContext(ctx, cfg)
+	defer cancel()
+
+	res, readCfg, err := ps.readConfig(ctx, cfg, client, filter)
+
+	if err == nil {
+		ps.setServedRegion("")
+
+		return res, readCfg, nil
 	}
 
-	// Check if AWS role ARN is present
-	if cfg.AWSRoleARN != "" {
-		stsSvc := sts.NewFromConfig(c)
-		credentials := stscreds.NewAssumeRoleProvider(stsSvc, cfg.AWSRoleARN)
-		c.Credentials = aws.NewCredentialsCache(credentials)
+	ps.mu.RLock()
+	ownsClient := ps.ownsClient
+	ps.mu.RUnlock()
+
+	if !ownsClient {
+		return nil, readCfg, err
 	}
 
-	client := ssm.NewFromConfig(c)
+	// Fall back to the replicas while the regions are unreachable
+	failed := ps.sourceRegion(ctx)
 
-	return &ParamStore{
-		client: client,
-		config: cfg,
-		cb:     cb,
+	for _, region := range cfg.FallbackRegions {
+		if ctx.Err() != nil || !isRegionFailure(err) {
+			break
+		}
+
+		logf(cfg.Logger, "paramstore: read failed in %s, falling back to %s: %v", failed, region, err)
+
+		failed = region
+		fallbackCfg := cfg
+		fallbackCfg.AWSRegion = region
+		fallbackCfg.RegionResolver = nil
+
+		fallback, _, clientErr := newClient(&fallbackCfg)
+
+		if clientErr != nil {
+			return nil, readCfg, clientErr
+		}
+
+		res, readCfg, err = ps.readConfig(withRegion(ctx, region), fallbackCfg, fallback, filter)
+
+		if err == nil {
+			ps.setServedRegion(region)
+
+			return res, readCfg, nil
+		}
 	}
+
+	return nil, readCfg, err
 }
 
-func ProviderWithClient(cfg Config, cb func(s string) string, client *ssm.Client) *ParamStore {
-	ps := &ParamStore{client: client, config: cfg}
+// operationContext derives the context of a whole read from parent.
+func operationContext(parent context.Context, cfg Config) (context.Context, context.CancelFunc) {
+	if cfg.OperationTimeout <= 0 {
+		return context.WithCancel(parent)
+	}
 
-	if cb != nil {
-		ps.cb = func(key, value string) (string, interface{}) {
-			return cb(key), value
+	return context.WithTimeout(parent, cfg.OperationTimeout)
+}
+
+// readOperation runs a read other than Read the way readConfig runs Read:
+// bounded by OperationTimeout, after BeforeRead and in a span. fn returns the
+// number of parameters it read.
+func readOperation(ctx context.Context, cfg Config, name string, fn func(ctx context.Context) (int, error)) error {
+	var err error
+
+	ctx, cancel := operationContext(ctx, cfg)
+	defer cancel()
+
+	// Let the caller prepare the context
+	if cfg.BeforeRead != nil {
+		if ctx, err = cfg.BeforeRead(ctx); err != nil {
+			return err
 		}
 	}
 
-	return ps
+	ctx, stats := withStats(ctx)
+	ctx, span := startSpan(ctx, cfg, name)
+
+	count, err := fn(ctx)
+
+	err = wrapClockSkew(err)
+
+	if err != nil && cfg.OperationTimeout > 0 && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		err = fmt.Errorf("read did not finish within OperationTimeout of %s: %w", cfg.OperationTimeout, err)
+	}
+
+	endSpan(span, stats.pages, count, err)
+
+	return err
 }
 
-func (ps *ParamStore) Read() (map[string]interface{}, error) {
+// setServedRegion records the fallback region that served the last read.
+func (ps *ParamStore) setServedRegion(region string) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	ps.servedRegion = region
+}
+
+// readConfig implements read for a config and client snapshot, which callers
+// may have adjusted. Callers bound ctx with operationContext.
+func (ps *ParamStore) readConfig(ctx context.Context, cfg Config, client SSMClient, filter func([]types.Parameter) []types.Parameter) (*Result, Config, error) {
+	var err error
+
+	start := time.Now()
+
 	// Check if path is provided
-	if ps.config.Path == "" {
-		return nil, errors.New("no parameter path provided")
+	if err := validate(cfg); err != nil {
+		return nil, cfg, err
 	}
 
-	// Set SSM API call input
-	ps.input = ssm.GetParametersByPathInput{
-		Path:             aws.String(ps.config.Path),
-		WithDecryption:   &ps.config.WithDecryption,
-		ParameterFilters: ps.config.ParameterFilters,
+	// Let the caller prepare the context
+	if cfg.BeforeRead != nil {
+		if ctx, err = cfg.BeforeRead(ctx); err != nil {
+			return nil, cfg, err
+		}
+	}
+
+	ctx, stats := withStats(ctx)
+	ctx, span := startSpan(ctx, cfg, "paramstore.Read")
+
+	res, err := ps.readWith(ctx, cfg, client, filter, start)
+
+	err = wrapClockSkew(err)
+
+	if err != nil && cfg.OperationTimeout > 0 && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		err = fmt.Errorf("read did not finish within OperationTimeout of %s: %w", cfg.OperationTimeout, err)
 	}
 
+	count := 0
+
+	if res != nil {
+		count = res.Count
+		res.pages = stats.pages
+	}
+
+	if res != nil && storesBaseline(ctx) {
+		ps.mu.Lock()
+		ps.pages = stats.pages
+		ps.decryptionFailures = stats.decryptionFailures
+		ps.truncated = stats.truncated
+		ps.warnings = stats.warnings
+		ps.mu.Unlock()
+	}
+
+	endSpan(span, stats.pages, count, err)
+
+	return res, cfg, err
+}
+
+// readWith implements read for a config and client snapshot.
+func (ps *ParamStore) readWith(ctx context.Context, cfg Config, client SSMClient, filter func([]types.Parameter) []types.Parameter, start time.Time) (*Result, error) {
+	// Set SSM API call input
+	input := pathInput(cfg)
+
 	// Get parameters
+	params, err := ps.collect(ctx, cfg, client, &input)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// Read again with decryption if the probe found SecureStrings
+	if cfg.AutoDecryptSecureStrings && !cfg.WithDecryption && cfg.DecryptPredicate == nil && hasSecureStrings(params) {
+		cfg.WithDecryption = true
+		input = pathInput(cfg)
+
+		params, err = ps.collect(ctx, cfg, client, &input)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Refuse to hand out ciphertext
+	if cfg.StrictDecryption && !cfg.WithDecryption && cfg.DecryptPredicate == nil && hasSecureStrings(params) {
+		return nil, errors.New("SecureString parameters found with WithDecryption disabled; enable WithDecryption or AutoDecryptSecureStrings")
+	}
+
+	if filter != nil {
+		params = filter(params)
+	}
+
+	// Substitute references to other parameters
+	if cfg.ResolveReferences {
+		params, err = resolveReferences(ctx, cfg, client, params)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Sort so the transformer is always called in the same order
+	sortParams(params, cfg)
+
+	// Fetch descriptions and allowed patterns if requested
+	var described map[string]types.ParameterMetadata
+
+	if (cfg.FetchDescriptions || cfg.ValidateAllowedPatterns) && cfg.Path != "" {
+		metas, err := describeParameters(ctx, cfg, client)
+
+		if err != nil {
+			return nil, err
+		}
+
+		described = make(map[string]types.ParameterMetadata, len(metas))
+
+		for _, meta := range metas {
+			described[aws.ToString(meta.Name)] = meta
+		}
+	}
+
+	// Check values against their own allowed patterns
+	if cfg.ValidateAllowedPatterns {
+		if err := validatePatterns(ctx, params, described); err != nil {
+			return nil, err
+		}
+	}
+
+	mp, sources, err := ps.flatten(ctx, cfg, params)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if storesBaseline(ctx) {
+		ps.mu.Lock()
+		ps.changes = Diff(ps.flat, mp)
+		ps.input = input
+		ps.params = params
+		ps.flat = mp
+		ps.described = described
+		ps.readAt = start
+		ps.mu.Unlock()
+	}
+
+	res := newResult(params, described, mp, start)
+	res.sources = sources
+
+	return res, nil
+}
+
+// pathInput builds the GetParametersByPath input for cfg.
+func pathInput(cfg Config) ssm.GetParametersByPathInput {
+	return ssm.GetParametersByPathInput{
+		MaxResults:       pageSize(cfg.PathPageSize, getParametersByPathMaxResults),
+		Path:             aws.String(cfg.Path),
+		WithDecryption:   aws.Bool(cfg.WithDecryption),
+		ParameterFilters: cfg.ParameterFilters,
+		Recursive:        aws.Bool(cfg.Recursive),
+	}
+}
+
+// collect fetches the parameters below Path and Paths and those discovered by
+// tag.
+func (ps *ParamStore) collect(ctx context.Context, cfg Config, client SSMClient, input *ssm.GetParametersByPathInput) ([]types.Parameter, error) {
 	var params []types.Parameter
 
-	for {
-		result, err := ps.client.GetParametersByPath(context.Background(), &ps.input)
+	// Read everything encrypted and decrypt the selected ones afterwards
+	if cfg.DecryptPredicate != nil {
+		cfg.WithDecryption = false
+		input.WithDecryption = aws.Bool(false)
+	}
+
+	// Name the failing source when reading from several
+	sources := len(cfg.Paths)
+
+	if cfg.Path != "" {
+		sources++
+	}
+
+	if len(cfg.DiscoverTags) > 0 {
+		sources++
+	}
+
+	multi := sources > 1
+
+	if cfg.Path != "" {
+		fetched, err := ps.fetch(ctx, cfg, client, input)
+
+		if err != nil && multi {
+			return nil, fmt.Errorf("reading %s in %s: %w", cfg.Path, ps.sourceRegion(ctx), err)
+		}
 
 		if err != nil {
 			return nil, err
 		}
 
-		params = append(params, result.Parameters...)
+		params = fetched
+	}
 
-		ps.input.NextToken = result.NextToken
+	// Read the further paths and merge them in
+	for _, spec := range cfg.Paths {
+		specCfg := cfg
+		specCfg.Path = spec.Path
+		specCfg.Recursive = spec.Recursive
+		specCfg.WithDecryption = aws.ToBool(input.WithDecryption)
+		specCfg.MaxDepth = 0
 
-		if result.NextToken == nil {
-			break
+		if spec.ParameterFilters != nil {
+			specCfg.ParameterFilters = spec.ParameterFilters
+		}
+
+		specInput := pathInput(specCfg)
+
+		fetched, err := ps.fetch(ctx, specCfg, client, &specInput)
+
+		if err != nil && multi {
+			return nil, fmt.Errorf("reading %s in %s: %w", spec.Path, ps.sourceRegion(ctx), err)
 		}
 
+		if err != nil {
+			return nil, err
+		}
+
+		params = mergeParameters(params, fetched)
+
+		if cfg.MaxParameters > 0 && len(params) > cfg.MaxParameters {
+			return nil, fmt.Errorf("%w: more than %d parameters including those below %s", ErrMaxParametersExceeded, cfg.MaxParameters, spec.Path)
+		}
 	}
 
-	ps.params = params
+	// Discover parameters by tag and merge them in
+	if len(cfg.DiscoverTags) > 0 {
+		tagged, err := fetchByTags(ctx, cfg, client)
+
+		if err != nil && multi {
+			return nil, fmt.Errorf("reading tagged parameters in %s: %w", ps.sourceRegion(ctx), err)
+		}
 
+		if err != nil {
+			return nil, err
+		}
+
+		params = mergeParameters(params, tagged)
+
+		if cfg.MaxParameters > 0 && len(params) > cfg.MaxParameters {
+			return nil, fmt.Errorf("%w: more than %d parameters including those discovered by tag", ErrMaxParametersExceeded, cfg.MaxParameters)
+		}
+	}
+
+	var decrypted map[string]bool
+
+	if cfg.DecryptPredicate != nil {
+		var err error
+
+		if params, decrypted, err = decryptSelected(ctx, cfg, client, params); err != nil {
+			return nil, err
+		}
+	}
+
+	// Remember which values are still ciphertext
+	if stats := statsFrom(ctx); stats != nil {
+		stats.encrypted = nil
+
+		if !aws.ToBool(input.WithDecryption) {
+			stats.encrypted = make(map[string]bool)
+
+			for _, param := range params {
+				if param.Type == types.ParameterTypeSecureString && !decrypted[aws.ToString(param.Name)] {
+					stats.encrypted[aws.ToString(param.Name)] = true
+				}
+			}
+		}
+	}
+
+	return params, nil
+}
+
+// flatten turns params into a flat map of transformed keys to values. The
+// transformer is called once per parameter, in the order of params. The
+// second map holds the parameter each key was read from.
+func (ps *ParamStore) flatten(ctx context.Context, cfg Config, params []types.Parameter) (map[string]interface{}, map[string]types.Parameter, error) {
 	mp := make(map[string]interface{})
+	sources := make(map[string]types.Parameter)
+	owners := make(map[string]string)
+
+	var errs []error
 
 	for _, param := range params {
-		key := *param.Name
-		var value any
+		// Leave empty values to the defaults
+		if cfg.TreatEmptyAsMissing && param.Value != nil && rawValue(cfg, param) == "" {
+			continue
+		}
 
-		// Transform key if transformer is provided
-		if ps.cb != nil {
-			key, value = ps.cb(key, *param.Value)
+		key, value, err := ps.transform(cfg, param)
+
+		if err != nil {
+			// Keep going to report every broken parameter at once
+			if cfg.CollectErrors {
+				errs = append(errs, fmt.Errorf("parameter %s: %w", aws.ToString(param.Name), err))
+
+				continue
+			}
+
+			return nil, nil, err
+		}
+
+		// Expand dotenv blobs into nested keys
+		if cfg.ExpandDotenv != nil && cfg.ExpandDotenv(key) {
+			entries, err := expandDotenv(key, value, cfg.Delimiter)
+
+			if err != nil {
+				if cfg.CollectErrors {
+					errs = append(errs, fmt.Errorf("parameter %s: %w", aws.ToString(param.Name), err))
+
+					continue
+				}
+
+				return nil, nil, err
+			}
+
+			for k, v := range entries {
+				mp[k] = v
+				sources[k] = param
+			}
+
+			continue
 		}
 
-		if key == "" {
-			return nil, errors.New("transformed key is empty")
+		// Later parameters win, but say so
+		if owner, ok := owners[key]; ok {
+			warnf(ctx, cfg, "%s and %s both map to key %s, keeping %s", owner, aws.ToString(param.Name), key, aws.ToString(param.Name))
 		}
 
+		owners[key] = aws.ToString(param.Name)
+
 		// Set key value
 		mp[key] = value
+		sources[key] = param
+	}
+
+	if len(errs) > 0 {
+		return nil, nil, errors.Join(errs...)
+	}
+
+	// Fill in defaults for keys missing from SSM
+	for key, value := range cfg.Defaults {
+		if _, ok := mp[key]; !ok {
+			mp[key] = value
+		}
+	}
+
+	// Render templates once all keys are known
+	if cfg.RenderTemplates {
+		if err := renderTemplates(cfg, mp); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return mp, sources, nil
+}
+
+// unflatten nests flat with cfg.Unflattener, falling back to maps.Unflatten.
+func unflatten(cfg Config, flat map[string]interface{}) map[string]interface{} {
+	if cfg.Unflattener != nil {
+		return cfg.Unflattener(flat, cfg.Delimiter)
+	}
+
+	return maps.Unflatten(flat, cfg.Delimiter)
+}
+
+// rawValue returns the parameter's value before transformation.
+func rawValue(cfg Config, param types.Parameter) string {
+	raw := aws.ToString(param.Value)
+
+	// Trim surrounding whitespace if requested
+	if cfg.TrimSpace {
+		raw = strings.TrimSpace(raw)
+	}
+
+	return raw
+}
+
+// recoverPanic runs fn, turning a panic into an error naming the parameter.
+func recoverPanic(name string, fn func()) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("transformer panicked on parameter %s: %v", name, r)
+		}
+	}()
+
+	fn()
+
+	return nil
+}
+
+// transform turns a single parameter into its final key and value, applying
+// delimiter handling, the transformers, key case and type coercion.
+func (ps *ParamStore) transform(cfg Config, param types.Parameter) (string, interface{}, error) {
+	if param.Name == nil {
+		return "", nil, errors.New("parameter has no name")
+	}
+
+	if param.Value == nil {
+		return "", nil, fmt.Errorf("parameter %s has no value", *param.Name)
+	}
+
+	key, err := escapeDelimiter(*param.Name, cfg)
+
+	if err != nil {
+		return "", nil, err
+	}
+
+	raw := rawValue(cfg, param)
+
+	var value any = raw
+
+	var handlerErr error
+
+	// Don't let a panicking transformer take down the process
+	err = recoverPanic(*param.Name, func() {
+		// Transform key if transformer is provided
+		if ps.cb != nil {
+			key, value = ps.cb(key, raw)
+		}
+
+		// Convert the value by SSM type
+		if handler, ok := cfg.TypeHandlers[param.Type]; ok {
+			if str, ok := value.(string); ok {
+				value, handlerErr = handler(str)
+			}
+		}
+
+		// Apply the transformer chain
+		for _, transform := range cfg.Transformers {
+			key = transform(key)
+		}
+	})
+
+	if err != nil {
+		return "", nil, err
+	}
+
+	if handlerErr != nil {
+		return "", nil, fmt.Errorf("parameter %s of type %s: %w", *param.Name, param.Type, handlerErr)
+	}
+
+	// Wrap decrypted secrets to keep them out of logs
+	if str, ok := value.(string); ok && cfg.WrapSecrets && param.Type == types.ParameterTypeSecureString {
+		value = SecretString(str)
+	}
+
+	// Normalize key case
+	key = cfg.KeyCase.apply(key)
+
+	// Clean up separators left by operators and transformers
+	if cfg.NormalizeDelimiters {
+		key = normalizeKey(key, cfg.Delimiter)
+	}
+
+	// Drop a fixed number of leading segments
+	if cfg.StripSegments > 0 {
+		key = stripSegments(key, cfg.Delimiter, cfg.StripSegments)
+	}
+
+	// Put the key back below the path
+	if cfg.NestUnderPath {
+		key = nestUnder(key, cfg.KeyCase.apply(cfg.Path), cfg.Delimiter)
+	}
+
+	// Drop the leading delimiter so unflatten doesn't add an empty root key
+	if !cfg.KeepLeadingDelimiter {
+		key = strings.TrimPrefix(key, cfg.Delimiter)
+	}
+
+	if key == "" {
+		return "", nil, errors.New("transformed key is empty")
+	}
+
+	// Check the SSM data type
+	if want, ok := cfg.ExpectedDataTypes[key]; ok {
+		got := aws.ToString(param.DataType)
+
+		if got == "" {
+			got = "text"
+		}
+
+		if got != want {
+			return "", nil, fmt.Errorf("parameter %s (key %s) has data type %s, expected %s", *param.Name, key, got, want)
+		}
+	}
+
+	// Coerce to the declared type
+	if typ, ok := cfg.ExpectedTypes[key]; ok {
+		coerced, err := coerce(value, typ)
+
+		if err != nil {
+			return "", nil, fmt.Errorf("parameter %s (key %s) is not a valid %s: %w", *param.Name, key, typ, err)
+		}
+
+		value = coerced
+	}
+
+	return key, value, nil
+}
+
+// fetch reads all parameters for input, applying the tolerant decryption
+// fallback when enabled.
+func (ps *ParamStore) fetch(ctx context.Context, cfg Config, client SSMClient, input *ssm.GetParametersByPathInput) ([]types.Parameter, error) {
+	params, err := ps.fetchPages(ctx, cfg, client, input)
+
+	if err != nil && cfg.WithDecryption && cfg.TolerateDecryptionErrors && isDecryptionError(err) {
+		params, err = ps.readTolerant(ctx, cfg, client, *input)
+	}
+
+	if err != nil && cfg.TolerateAccessDenied && isAccessDenied(err) {
+		params, err = ps.readDenied(ctx, cfg, client, *input)
+	}
+
+	// Point at KMS, since the SSM error alone rarely makes that obvious
+	if err != nil && isDecryptionError(err) {
+		return nil, fmt.Errorf("decrypting SecureStrings below %s failed, check the KMS key policy and grants for the caller: %w", aws.ToString(input.Path), err)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return filterName(filterDepth(params, cfg), cfg), nil
+}
+
+// filterName drops parameters whose name doesn't start with
+// cfg.NameBeginsWith.
+func filterName(params []types.Parameter, cfg Config) []types.Parameter {
+	if cfg.NameBeginsWith == "" {
+		return params
+	}
+
+	out := params[:0]
+
+	for _, param := range params {
+		if strings.HasPrefix(aws.ToString(param.Name), cfg.NameBeginsWith) {
+			out = append(out, param)
+		}
+	}
+
+	return out
+}
+
+// filterDepth drops parameters nested more than cfg.MaxDepth segments below
+// cfg.Path.
+func filterDepth(params []types.Parameter, cfg Config) []types.Parameter {
+	if cfg.MaxDepth <= 0 {
+		return params
+	}
+
+	prefix := strings.TrimSuffix(cfg.Path, "/") + "/"
+	out := params[:0]
+
+	for _, param := range params {
+		rel := strings.TrimPrefix(aws.ToString(param.Name), prefix)
+
+		if strings.Count(rel, "/")+1 <= cfg.MaxDepth {
+			out = append(out, param)
+		}
+	}
+
+	return out
+}
+
+// paramID identifies param by its ARN, or by its name for backends that
+// don't return ARNs.
+func paramID(param types.Parameter) string {
+	if arn := aws.ToString(param.ARN); arn != "" {
+		return arn
+	}
+
+	return aws.ToString(param.Name)
+}
+
+// fetchPages pages through GetParametersByPath until all parameters for
+// input have been collected, reporting progress after every page.
+func (ps *ParamStore) fetchPages(ctx context.Context, cfg Config, client SSMClient, input *ssm.GetParametersByPathInput) ([]types.Parameter, error) {
+	var params []types.Parameter
+
+	err := eachPage(ctx, cfg, client, input, func(page []types.Parameter) error {
+		params = append(params, page...)
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
 	}
 
-	return maps.Unflatten(mp, ps.config.Delimiter), nil
+	return params, nil
+}
+
+// eachPage pages through GetParametersByPath for input and calls fn with the
+// parameters of every page not already returned by an earlier one. It
+// applies the rate limit and the page and parameter limits, and reports
+// progress after every page.
+func eachPage(ctx context.Context, cfg Config, client SSMClient, input *ssm.GetParametersByPathInput, fn func([]types.Parameter) error) error {
+	start := time.Now()
+
+	stats := statsFrom(ctx)
+
+	seen := make(map[string]bool)
+
+	for page := 1; ; page++ {
+		// Wait for the rate limit
+		if cfg.RateLimiter != nil {
+			if err := cfg.RateLimiter.Wait(ctx); err != nil {
+				return err
+			}
+		}
+
+		callStart := time.Now()
+
+		result, err := client.GetParametersByPath(ctx, input)
+
+		observeCall(cfg, callStart, result, err)
+
+		if err != nil {
+			return err
+		}
+
+		if stats != nil {
+			stats.pages++
+		}
+
+		// Drop parameters already returned by an earlier page
+		var params []types.Parameter
+
+		for _, param := range result.Parameters {
+			id := paramID(param)
+
+			if seen[id] && cfg.StrictPagination {
+				return fmt.Errorf("%s returned more than once on page %d below %s", id, page, aws.ToString(input.Path))
+			}
+
+			if seen[id] {
+				warnf(ctx, cfg, "dropping duplicate %s on page %d below %s", id, page, aws.ToString(input.Path))
+
+				continue
+			}
+
+			seen[id] = true
+			params = append(params, param)
+		}
+
+		// Stop before collecting an unexpectedly large tree
+		if cfg.MaxParameters > 0 && len(seen) > cfg.MaxParameters {
+			return fmt.Errorf("%w: more than %d parameters below %s", ErrMaxParametersExceeded, cfg.MaxParameters, aws.ToString(input.Path))
+		}
+
+		if err := fn(params); err != nil {
+			return err
+		}
+
+		// Report progress
+		logf(cfg.Logger, "paramstore: fetched page %d of %s (%d parameters, %s elapsed)", page, aws.ToString(input.Path), len(seen), time.Since(start))
+
+		if cfg.ReadProgress != nil {
+			cfg.ReadProgress(len(seen))
+		}
+
+		if cfg.OnPage != nil {
+			cfg.OnPage(result.Parameters, page)
+		}
+
+		// Dump the raw page if debugging
+		if cfg.Debug {
+			debugPage(cfg.Logger, page, result)
+		}
+
+		input.NextToken = result.NextToken
+
+		if result.NextToken == nil {
+			return nil
+		}
+
+		// Deliberately stop early when simulating smaller trees
+		if cfg.SimulateMaxPages > 0 && page >= cfg.SimulateMaxPages {
+			if stats != nil {
+				stats.truncated = true
+			}
+
+			return nil
+		}
+
+		// Guard against runaway reads
+		if cfg.MaxPages > 0 && page >= cfg.MaxPages {
+			return fmt.Errorf("%w: more than %d pages below %s", ErrMaxPagesExceeded, cfg.MaxPages, aws.ToString(input.Path))
+		}
+	}
 }
 
+// ReadBytes serializes the config returned by Read with Config.Parser, or as
+// JSON if no parser is set, so k.Load(provider, parser) yields the same
+// structure as k.Load(provider, nil). SecretString values are revealed, since
+// the bytes are meant to be parsed rather than logged.
 func (ps *ParamStore) ReadBytes() ([]byte, error) {
-	return nil, errors.New("paramstore provider does not support ReadBytes method")
+	res, cfg, err := ps.read(context.Background(), nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	mp := unflatten(cfg, revealSecrets(res.Flat))
+
+	if cfg.Parser != nil {
+		return cfg.Parser.Marshal(mp)
+	}
+
+	return json.Marshal(mp)
 }
 
 func (ps *ParamStore) Watch(cb func(event interface{}, err error)) error {
+	return ps.WatchContext(context.Background(), cb)
+}
+
+// WatchContext is like Watch, but stops once ctx is done. Every tick runs
+// with a context derived from ctx, bounded by WatchFetchTimeout if set, so
+// cancelling ctx also aborts an in-flight fetch.
+func (ps *ParamStore) WatchContext(ctx context.Context, cb func(event interface{}, err error)) error {
+	ps.mu.RLock()
+	primed := ps.input.Path != nil
+	ps.mu.RUnlock()
+
+	// Prime the input and baseline if Read hasn't been called yet
+	if !primed {
+		if _, _, err := ps.read(ctx, nil); err != nil {
+			return err
+		}
+	}
+
+	ps.mu.RLock()
+	cfg := ps.config
+	initial := append([]types.Parameter{}, ps.params...)
+	ps.mu.RUnlock()
+
 	go func() {
+		// Report the baseline so callers know the watch is live
+		if cfg.WatchEmitInitial {
+			notify(cfg, cb, watchEvent(cfg, nil, initial), nil)
+		}
+
 		// Start new ticker
-		ticker := time.NewTicker(ps.config.WatchInterval)
+		ticker := time.NewTicker(cfg.WatchInterval)
 		defer ticker.Stop()
 
-	main:
-		for range ticker.C {
-			// Initialize slice to store parameters fetched from API
-			var params []types.Parameter
+		var streak errorStreak
+
+		// Changes held back until WatchDebounce passes without new ones, and
+		// those already reported, since the baseline doesn't move without
+		// WatchRefresh
+		var pending, reported []types.Parameter
+		var debounce *time.Timer
+
+		// The baseline versions of the changes not yet reported
+		prior := make(priorValues)
+		var debounced <-chan time.Time
+
+		defer func() {
+			if debounce != nil {
+				debounce.Stop()
+			}
+		}()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-debounced:
+				notify(cfg, cb, watchEvent(cfg, prior, pending), nil)
+
+				reported, _ = mergePending(reported, pending)
+				pending, debounced = nil, nil
+
+				continue
+			case <-ticker.C:
+			}
+
 			// Initialize slice to store updated parameters
 			var updatedParams []types.Parameter
 
-			// Fetch all parameters from API
-			for {
-				result, err := ps.client.GetParametersByPath(context.Background(), &ps.input)
+			// Take a consistent snapshot of the client, input and baseline
+			ps.mu.RLock()
+			cfg := ps.config
+			client := ps.client
+			decrypted := aws.ToBool(ps.input.WithDecryption)
+			baseline := ps.params
+			ps.mu.RUnlock()
 
-				if err != nil {
-					cb(nil, err)
+			tickCtx, cancel := tickContext(ctx, cfg)
 
-					continue main
+			// Stop quietly once the watch is cancelled
+			fail := func(err error) bool {
+				cancel()
+
+				return ctx.Err() != nil || watchFailed(cfg, cb, &streak, err)
+			}
+
+			fetchCtx := tickCtx
+
+			// Let the caller prepare the context
+			if cfg.BeforeRead != nil {
+				var err error
+
+				if fetchCtx, err = cfg.BeforeRead(fetchCtx); err != nil {
+					if fail(err) {
+						return
+					}
+
+					continue
 				}
+			}
 
-				params = append(params, result.Parameters...)
+			// Fetch all parameters from API
+			fetchCtx, stats := withStats(fetchCtx)
+			fetchCtx, span := startSpan(fetchCtx, cfg, "paramstore.Watch.tick")
 
-				ps.input.NextToken = result.NextToken
+			// Scope the watch like Read, keeping decryption a probe turned on
+			input := pathInput(cfg)
 
-				if result.NextToken == nil {
-					break
+			if cfg.AutoDecryptSecureStrings && decrypted {
+				input.WithDecryption = aws.Bool(true)
+			}
+
+			params, err := ps.collect(fetchCtx, cfg, client, &input)
+
+			err = wrapClockSkew(err)
+
+			endSpan(span, stats.pages, len(params), err)
+
+			if err != nil {
+				if fail(err) {
+					return
 				}
+
+				continue
 			}
 
 			// Check for updates
@@ -237,8 +1611,8 @@ func (ps *ParamStore) Watch(cb func(event interface{}, err error)) error {
 				var found bool
 
 				// Find parameter in previously saved parameters
-				for _, p := range ps.params {
-					if *p.ARN == *newParam.ARN {
+				for _, p := range baseline {
+					if paramID(p) == paramID(newParam) {
 						found = true
 
 						if newParam.Version != p.Version {
@@ -252,9 +1626,62 @@ func (ps *ParamStore) Watch(cb func(event interface{}, err error)) error {
 				}
 			}
 
-			if len(updatedParams) > 0 {
+			// Swap in the new snapshot before anyone is notified
+			if cfg.WatchRefresh && len(updatedParams) > 0 {
+				if _, _, err := ps.read(tickCtx, nil); err != nil {
+					if fail(err) {
+						return
+					}
+
+					continue
+				}
+			}
+
+			cancel()
+
+			watchSucceeded(cfg, &streak)
+
+			// Only report changes to the watched keys
+			if len(cfg.WatchKeys) > 0 {
+				updatedParams = ps.filterWatched(cfg, updatedParams)
+			}
+
+			if len(updatedParams) == 0 {
+				// Don't claim a quiet tick while debounced changes are pending
+				if len(pending) == 0 {
+					heartbeat(cfg, cb)
+				}
+
+				continue
+			}
+
+			if cfg.WatchPrevious {
+				prior.record(baseline, updatedParams)
+			}
+
+			if cfg.WatchDebounce <= 0 {
 				// Trigger update
-				cb(updatedParams, nil)
+				notify(cfg, cb, watchEvent(cfg, prior, updatedParams), nil)
+
+				continue
+			}
+
+			// Restart the quiet period only for changes not seen before
+			var changed bool
+
+			if pending, changed = mergePending(pending, unreported(reported, updatedParams)); changed {
+				if debounce != nil {
+					debounce.Stop()
+				}
+
+				debounce = time.NewTimer(cfg.WatchDebounce)
+				debounced = debounce.C
+			}
+
+			// Nothing was reported yet on this tick, but changes may be
+			// pending
+			if len(pending) == 0 {
+				heartbeat(cfg, cb)
 			}
 		}
 	}()
@@ -0,0 +1,457 @@
+package paramstore
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	"github.com/knadh/koanf/parsers/json"
+	"github.com/knadh/koanf/parsers/yaml"
+	"github.com/knadh/koanf/v2"
+	"golang.org/x/time/rate"
+)
+
+func TestReadDuplicateARNsAcrossPages(t *testing.T) {
+	fake := newFakeSSM("/app/a", "1", "/app/b", "2")
+	a, b := fake.params["/app/a"], fake.params["/app/b"]
+	fake.pages = [][]types.Parameter{{a, b}, {a}}
+
+	ps := ProviderWithClient(Config{Path: "/app"}, nil, fake)
+	mp, err := ps.Read()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := len(mp["app"].(map[string]interface{})); got != 2 || ps.Count() != 2 {
+		t.Fatalf("got %d keys and %d parameters, want 2", got, ps.Count())
+	}
+
+	if warnings := ps.Warnings(); len(warnings) != 1 || !strings.Contains(warnings[0], fakeARN("/app/a")) {
+		t.Fatalf("got warnings %q, want one for the duplicate", warnings)
+	}
+
+	_, err = ProviderWithClient(Config{Path: "/app", StrictPagination: true}, nil, fake).Read()
+
+	if err == nil || !strings.Contains(err.Error(), fakeARN("/app/a")) {
+		t.Fatalf("got %v, want an error naming the duplicate ARN", err)
+	}
+}
+
+const testPEM = `-----BEGIN CERTIFICATE-----
+MIIBszCCAVmgAwIBAgIUQk2
+dGVzdCBjZXJ0aWZpY2F0ZQ==
+-----END CERTIFICATE-----
+`
+
+func TestReadPreservesMultilineSecureString(t *testing.T) {
+	fake := newFakeSSM()
+	fake.set("/app/tls/cert", testPEM, types.ParameterTypeSecureString)
+	fake.set("/app/tls/crlf", "line1\r\nline2\r\n", types.ParameterTypeSecureString)
+
+	mp, err := ProviderWithClient(Config{Path: "/app", Recursive: true, WithDecryption: true}, nil, fake).Read()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tls := mp["app"].(map[string]interface{})["tls"].(map[string]interface{})
+
+	if got := tls["cert"]; got != testPEM {
+		t.Fatalf("got %q, want the PEM unchanged", got)
+	}
+
+	if got := tls["crlf"]; got != "line1\r\nline2\r\n" {
+		t.Fatalf("got %q, want the line endings unchanged", got)
+	}
+
+	mp, err = ProviderWithClient(Config{Path: "/app", Recursive: true, WithDecryption: true, TrimSpace: true}, nil, fake).Read()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := mp["app"].(map[string]interface{})["tls"].(map[string]interface{})["cert"]; got != strings.TrimSpace(testPEM) {
+		t.Fatalf("got %q, want the PEM without the trailing newline", got)
+	}
+}
+
+func TestTypeHandlers(t *testing.T) {
+	type endpoint struct {
+		Host string
+		Port string
+	}
+
+	fake := newFakeSSM("/app/name", "n")
+	fake.set("/app/hosts", "a,b,c", types.ParameterTypeStringList)
+	fake.set("/app/db", "db.local:5432", types.ParameterTypeSecureString)
+
+	handlers := map[types.ParameterType]func(string) (interface{}, error){
+		types.ParameterTypeStringList: SplitStringList,
+		types.ParameterTypeSecureString: func(s string) (interface{}, error) {
+			host, port, ok := strings.Cut(s, ":")
+
+			if !ok {
+				return nil, errors.New("missing port")
+			}
+
+			return endpoint{host, port}, nil
+		},
+	}
+
+	mp, err := ProviderWithClient(Config{Path: "/app", WithDecryption: true, TypeHandlers: handlers}, nil, fake).Read()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	app := mp["app"].(map[string]interface{})
+
+	if got := app["hosts"]; !reflect.DeepEqual(got, []string{"a", "b", "c"}) {
+		t.Fatalf("got %#v, want the list split", got)
+	}
+
+	if got := app["db"]; got != (endpoint{"db.local", "5432"}) {
+		t.Fatalf("got %#v, want the custom type", got)
+	}
+
+	// Types without a handler keep their string
+	if got := app["name"]; got != "n" {
+		t.Fatalf("got %#v, want the string", got)
+	}
+
+	fake.set("/app/db", "db.local", types.ParameterTypeSecureString)
+
+	_, err = ProviderWithClient(Config{Path: "/app", WithDecryption: true, TypeHandlers: handlers}, nil, fake).Read()
+
+	if err == nil || !strings.Contains(err.Error(), "/app/db") || !strings.Contains(err.Error(), "missing port") {
+		t.Fatalf("got %v, want the handler error for /app/db", err)
+	}
+}
+
+func TestReadReportsIncompleteParameters(t *testing.T) {
+	for name, cfg := range map[string]Config{
+		"path":       {Path: "/app"},
+		"references": {Path: "/app", ResolveReferences: true},
+		"paths":      {Path: "/app", Paths: PathSpecs(false, "/other")},
+		"tags":       {Path: "/app", DiscoverTags: map[string][]string{"team": {"core"}}},
+		"depth":      {Path: "/app", MaxDepth: 1},
+	} {
+		t.Run(name, func(t *testing.T) {
+			fake := newFakeSSM("/app/a", "1")
+			fake.pages = [][]types.Parameter{{
+				fake.params["/app/a"],
+				{Value: aws.String("nameless")},
+				{Name: aws.String("/app/empty")},
+			}}
+
+			cfg.CollectErrors = true
+
+			_, err := ProviderWithClient(cfg, nil, fake).Read()
+
+			if err == nil || !strings.Contains(err.Error(), "parameter has no name") || !strings.Contains(err.Error(), "/app/empty has no value") {
+				t.Fatalf("got %v, want both incomplete parameters reported", err)
+			}
+		})
+	}
+
+	t.Run("since", func(t *testing.T) {
+		fake := newFakeSSM()
+		fake.pages = [][]types.Parameter{{{Value: aws.String("nameless")}}}
+
+		_, _, err := ProviderWithClient(Config{Path: "/app"}, nil, fake).ReadSince(map[string]int64{"/app/a": 2})
+
+		if err == nil || !strings.Contains(err.Error(), "parameter has no name") {
+			t.Fatalf("got %v, want the nameless parameter reported", err)
+		}
+	})
+}
+
+func TestReadBytesMatchesRead(t *testing.T) {
+	fake := newFakeSSM("/app/db/host", "localhost", "/app/db/port", "5432", "/app/name", "svc")
+	cb := PathTransformer("/app", ".")
+
+	for name, parser := range map[string]koanf.Parser{"json": json.Parser(), "yaml": yaml.Parser()} {
+		t.Run(name, func(t *testing.T) {
+			want := koanf.New(".")
+
+			if err := want.Load(ProviderWithClient(Config{Path: "/app", Recursive: true}, cb, fake), nil); err != nil {
+				t.Fatal(err)
+			}
+
+			got := koanf.New(".")
+
+			if err := got.Load(ProviderWithClient(Config{Path: "/app", Recursive: true, Parser: parser}, cb, fake), parser); err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(got.All(), want.All()) {
+				t.Fatalf("got %v, want %v", got.All(), want.All())
+			}
+
+			if got.String("db.host") != "localhost" {
+				t.Fatalf("got %v, want the nested structure", got.All())
+			}
+		})
+	}
+}
+
+func TestTransformersChain(t *testing.T) {
+	fake := newFakeSSM("/app/db/host", "localhost")
+
+	var calls []string
+
+	cb := func(key string) string {
+		calls = append(calls, "cb "+key)
+
+		return strings.TrimPrefix(key, "/app/")
+	}
+
+	cfg := Config{
+		Path:      "/app",
+		Recursive: true,
+		Delimiter: ".",
+		KeyCase:   KeyCaseLower,
+		Transformers: []func(string) string{
+			func(key string) string {
+				calls = append(calls, "slashes "+key)
+
+				return strings.ReplaceAll(key, "/", ".")
+			},
+			func(key string) string {
+				calls = append(calls, "upper "+key)
+
+				return strings.ToUpper(key)
+			},
+		},
+	}
+
+	mp, err := ProviderWithClient(cfg, cb, fake).Read()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The constructor transformer runs first, KeyCase last
+	want := []string{"cb /app/db/host", "slashes db/host", "upper db.host"}
+
+	if !reflect.DeepEqual(calls, want) {
+		t.Fatalf("got calls %q, want %q", calls, want)
+	}
+
+	if got := mp["db"].(map[string]interface{})["host"]; got != "localhost" {
+		t.Fatalf("got %v, want db.host", mp)
+	}
+}
+
+func TestTreatEmptyAsMissing(t *testing.T) {
+	fake := newFakeSSM("/app/host", "  ", "/app/port", "5432")
+
+	for name, tc := range map[string]struct {
+		empty bool
+		want  map[string]interface{}
+	}{
+		"kept":    {want: map[string]interface{}{"host": "", "port": "5432"}},
+		"missing": {empty: true, want: map[string]interface{}{"host": "localhost", "port": "5432"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			cfg := Config{
+				Path:                "/app",
+				Delimiter:           ".",
+				TrimSpace:           true,
+				TreatEmptyAsMissing: tc.empty,
+				Defaults:            map[string]interface{}{"host": "localhost"},
+			}
+
+			mp, err := ProviderWithClient(cfg, PathTransformer("/app", "."), fake).Read()
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(mp, tc.want) {
+				t.Fatalf("got %v, want %v", mp, tc.want)
+			}
+		})
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	fake := newFakeSSM("/app/a", "1", "/app/b", "2", "/app/c", "3")
+	fake.pageSize = 1
+
+	// Three pages at 100 calls per second take at least 20ms
+	start := time.Now()
+
+	if _, err := ProviderWithClient(Config{Path: "/app", RateLimit: 100}, nil, fake).Read(); err != nil {
+		t.Fatal(err)
+	}
+
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+		t.Fatalf("three pages took %s, want them spaced 10ms apart", elapsed)
+	}
+
+	// A shared limiter spaces the calls of both providers
+	limiter := rate.NewLimiter(50, 1)
+	start = time.Now()
+
+	for i := 0; i < 2; i++ {
+		if _, err := ProviderWithClient(Config{Path: "/app", RateLimiter: limiter}, nil, fake).Read(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Fatalf("six pages took %s, want them spaced 20ms apart", elapsed)
+	}
+}
+
+func TestMultiSourceErrorNamesSource(t *testing.T) {
+	srv := newSSMServer(t, newFakeSSM("/app/a", "1", "/shared/b", "2"))
+
+	// Deny reads of one source
+	srv.hook = func(w http.ResponseWriter, r *http.Request) bool {
+		body, _ := io.ReadAll(r.Body)
+
+		if !bytes.Contains(body, []byte(`"Path":"/shared"`)) {
+			return false
+		}
+
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"__type":"AccessDeniedException","message":"denied"}`))
+
+		return true
+	}
+
+	cfg := srv.config("/app")
+	cfg.Paths = []PathSpec{{Path: "/shared"}}
+
+	ps := Provider(cfg, nil)
+
+	if ps == nil {
+		t.Fatal("Provider failed")
+	}
+
+	_, err := ps.Read()
+
+	if err == nil || !strings.Contains(err.Error(), "reading /shared in us-east-1") {
+		t.Fatalf("got %v, want the failing path and region", err)
+	}
+}
+
+func TestReadOperationTimeout(t *testing.T) {
+	ps := ProviderWithClient(Config{
+		Path:             "/app",
+		OperationTimeout: 20 * time.Millisecond,
+		BeforeRead: func(ctx context.Context) (context.Context, error) {
+			<-ctx.Done()
+
+			return ctx, nil
+		},
+	}, nil, newFakeSSM("/app/a", "1"))
+
+	_, err := ps.Read()
+
+	if !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), "OperationTimeout") {
+		t.Fatalf("got %v, want the OperationTimeout to apply", err)
+	}
+}
+
+func TestTransformerPanic(t *testing.T) {
+	fake := newFakeSSM("/app/a", "1", "/app/bad", "2")
+	cb := func(key string) string {
+		if key == "/app/bad" {
+			panic("unexpected key")
+		}
+
+		return key
+	}
+
+	_, err := ProviderWithClient(Config{Path: "/app"}, cb, fake).Read()
+
+	if err == nil || !strings.Contains(err.Error(), "/app/bad") || !strings.Contains(err.Error(), "unexpected key") {
+		t.Fatalf("got %v, want the parameter and panic value", err)
+	}
+
+	// The Transformers chain is covered as well
+	_, err = ProviderWithClient(Config{Path: "/app", Transformers: []func(string) string{cb}}, nil, fake).Read()
+
+	if err == nil || !strings.Contains(err.Error(), "/app/bad") {
+		t.Fatalf("got %v, want the panic reported", err)
+	}
+}
+
+func TestTransformerPanicInWatch(t *testing.T) {
+	fake := newFakeSSM("/app/a", "1")
+	cb := func(key string) string {
+		if key == "/app/bad" {
+			panic("unexpected key")
+		}
+
+		return key
+	}
+
+	ps := ProviderWithClient(Config{Path: "/app", WatchInterval: 5 * time.Millisecond, WatchRefresh: true}, cb, fake)
+
+	if _, err := ps.Read(); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	rec := &watchRecorder{}
+
+	if err := ps.WatchContext(ctx, rec.cb); err != nil {
+		t.Fatal(err)
+	}
+
+	// The refresh must report the panic instead of crashing the watch
+	fake.set("/app/bad", "2", types.ParameterTypeString)
+
+	waitFor(t, func() bool {
+		return len(rec.errors()) > 0
+	})
+
+	if err := rec.errors()[0]; !strings.Contains(err.Error(), "/app/bad") {
+		t.Fatalf("got %v, want the panic reported", err)
+	}
+}
+
+func TestValidateAllowedPatternsSkipsCiphertext(t *testing.T) {
+	fake := newFakeSSM()
+	fake.set("/app/pin", "1234", types.ParameterTypeSecureString)
+	fake.set("/app/token", "abc", types.ParameterTypeSecureString)
+	fake.patterns = map[string]string{"/app/pin": "^[0-9]+$", "/app/token": "^[0-9]+$"}
+
+	cfg := Config{
+		Path:                    "/app",
+		WithDecryption:          true,
+		ValidateAllowedPatterns: true,
+		DecryptPredicate: func(name string) bool {
+			return name == "/app/pin"
+		},
+	}
+
+	// The undecrypted token isn't checked against the pattern
+	if _, err := ProviderWithClient(cfg, nil, fake).Read(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Once decrypted, it is
+	cfg.DecryptPredicate = nil
+
+	_, err := ProviderWithClient(cfg, nil, fake).Read()
+
+	if err == nil || !strings.Contains(err.Error(), "/app/token") {
+		t.Fatalf("got %v, want the token's value rejected", err)
+	}
+}
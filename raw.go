@@ -0,0 +1,52 @@
+package paramstore
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+// ReadRaw returns the value of every parameter Read would fetch keyed by its
+// full SSM name. No transformer, key handling, type coercion, TrimSpace or
+// defaults are applied and nothing is unflattened. The parameters are fetched
+// as in Read: Path and Paths, DiscoverTags, NameBeginsWith, MaxDepth, all
+// pages, decryption according to WithDecryption and DecryptPredicate,
+// OperationTimeout, BeforeRead and the Tracer ("paramstore.ReadRaw") apply.
+// The read baseline isn't updated.
+func (ps *ParamStore) ReadRaw() (map[string]string, error) {
+	cfg, client, err := ps.snapshot()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validate(cfg); err != nil {
+		return nil, err
+	}
+
+	var raw map[string]string
+
+	err = readOperation(context.Background(), cfg, "paramstore.ReadRaw", func(ctx context.Context) (int, error) {
+		input := pathInput(cfg)
+
+		params, err := ps.collect(ctx, cfg, client, &input)
+
+		if err != nil {
+			return 0, err
+		}
+
+		raw = make(map[string]string, len(params))
+
+		for _, param := range params {
+			raw[aws.ToString(param.Name)] = aws.ToString(param.Value)
+		}
+
+		return len(params), nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return raw, nil
+}
@@ -0,0 +1,70 @@
+package paramstore
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestReadRawReadsLikeRead(t *testing.T) {
+	fake := newFakeSSM(
+		"/app/a", "1",
+		"/app/db/host", "h",
+		"/app/db/deep/x", "too deep",
+		"/shared/a", "2",
+	)
+
+	tracer := &testTracer{}
+	prepared := false
+
+	ps := ProviderWithClient(Config{
+		Path:      "/app",
+		Paths:     PathSpecs(false, "/shared"),
+		Recursive: true,
+		MaxDepth:  2,
+		Tracer:    tracer,
+		BeforeRead: func(ctx context.Context) (context.Context, error) {
+			prepared = true
+
+			return ctx, nil
+		},
+	}, nil, fake)
+
+	raw, err := ps.ReadRaw()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{"/app/a": "1", "/app/db/host": "h", "/shared/a": "2"}
+
+	if !reflect.DeepEqual(raw, want) {
+		t.Fatalf("got %v, want %v", raw, want)
+	}
+
+	if !prepared {
+		t.Fatal("BeforeRead wasn't called")
+	}
+
+	if len(tracer.spans) != 1 || tracer.spans[0] != "paramstore.ReadRaw" {
+		t.Fatalf("got spans %q", tracer.spans)
+	}
+}
+
+func TestReadRawOperationTimeout(t *testing.T) {
+	ps := ProviderWithClient(Config{
+		Path:             "/app",
+		OperationTimeout: 20 * time.Millisecond,
+		BeforeRead: func(ctx context.Context) (context.Context, error) {
+			<-ctx.Done()
+
+			return ctx, nil
+		},
+	}, nil, newFakeSSM("/app/a", "1"))
+
+	if _, err := ps.ReadRaw(); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got %v, want the OperationTimeout to apply", err)
+	}
+}
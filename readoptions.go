@@ -0,0 +1,98 @@
+package paramstore
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+// ReadOptions overrides parts of the config for a single ReadWithContext
+// call. Zero values keep the configured setting.
+type ReadOptions struct {
+	Path             string
+	WithDecryption   *bool
+	ParameterFilters []types.ParameterStringFilter
+	// PageSize overrides PathPageSize.
+	PageSize int
+}
+
+// ReadPath reads path instead of Path.
+func ReadPath(path string) func(*ReadOptions) {
+	return func(o *ReadOptions) {
+		o.Path = path
+	}
+}
+
+// ReadDecrypted overrides WithDecryption.
+func ReadDecrypted(decrypt bool) func(*ReadOptions) {
+	return func(o *ReadOptions) {
+		o.WithDecryption = &decrypt
+	}
+}
+
+// ReadFilters replaces ParameterFilters.
+func ReadFilters(filters ...types.ParameterStringFilter) func(*ReadOptions) {
+	return func(o *ReadOptions) {
+		o.ParameterFilters = filters
+	}
+}
+
+// ReadPageSize overrides PathPageSize.
+func ReadPageSize(n int) func(*ReadOptions) {
+	return func(o *ReadOptions) {
+		o.PageSize = n
+	}
+}
+
+// ReadWithContext reads like Read, bounded by ctx, with the given per-call
+// overrides of the config, see ReadPath, ReadDecrypted, ReadFilters and
+// ReadPageSize. Without options it is exactly Read. With options, the read
+// baseline isn't updated, so Watch and the diffs keep comparing against the
+// configured tree.
+func (ps *ParamStore) ReadWithContext(ctx context.Context, opts ...func(*ReadOptions)) (map[string]interface{}, error) {
+	cfg, client, err := ps.snapshot()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(opts) > 0 {
+		var o ReadOptions
+
+		for _, opt := range opts {
+			opt(&o)
+		}
+
+		cfg = o.apply(cfg)
+		ctx = withoutBaseline(ctx)
+	}
+
+	res, cfg, err := ps.readFrom(ctx, cfg, client, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return res.config(cfg), nil
+}
+
+// apply returns cfg with the overrides of o.
+func (o ReadOptions) apply(cfg Config) Config {
+	if o.Path != "" {
+		cfg.Path = o.Path
+	}
+
+	if o.WithDecryption != nil {
+		cfg.WithDecryption = *o.WithDecryption
+	}
+
+	if o.ParameterFilters != nil {
+		cfg.ParameterFilters = o.ParameterFilters
+	}
+
+	if o.PageSize > 0 {
+		cfg.PathPageSize = o.PageSize
+	}
+
+	return cfg
+}
@@ -0,0 +1,120 @@
+package paramstore
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+// maxReferenceDepth bounds how deep references to references are followed.
+const maxReferenceDepth = 10
+
+// referencePattern matches CloudFormation style parameter references such as
+// {{resolve:ssm:/shared/db/host}} or {{resolve:ssm:/shared/db/host:3}}.
+var referencePattern = regexp.MustCompile(`\{\{resolve:ssm:([^}]+)\}\}`)
+
+// resolver substitutes parameter references in values.
+type resolver struct {
+	ctx    context.Context
+	cfg    Config
+	client SSMClient
+	// values holds known parameter values by name, either from the read
+	// itself or fetched while resolving
+	values map[string]string
+}
+
+// resolveReferences returns params with all references in their values
+// replaced by the referenced parameters' values. Referenced parameters that
+// weren't part of the read are fetched with GetParameter. Cycles and chains
+// deeper than maxReferenceDepth are errors.
+func resolveReferences(ctx context.Context, cfg Config, client SSMClient, params []types.Parameter) ([]types.Parameter, error) {
+	r := &resolver{ctx: ctx, cfg: cfg, client: client, values: make(map[string]string, len(params))}
+
+	for _, param := range params {
+		if param.Name != nil && param.Value != nil {
+			r.values[*param.Name] = *param.Value
+		}
+	}
+
+	out := make([]types.Parameter, len(params))
+
+	for i, param := range params {
+		// Leave incomplete parameters for transform to report
+		if param.Name == nil || param.Value == nil {
+			out[i] = param
+
+			continue
+		}
+
+		value, err := r.substitute(*param.Value, []string{*param.Name})
+
+		if err != nil {
+			return nil, err
+		}
+
+		param.Value = aws.String(value)
+		out[i] = param
+	}
+
+	return out, nil
+}
+
+// substitute replaces the references in value. stack holds the chain of
+// parameters that led here, for cycle detection.
+func (r *resolver) substitute(value string, stack []string) (string, error) {
+	var err error
+
+	value = referencePattern.ReplaceAllStringFunc(value, func(match string) string {
+		if err != nil {
+			return match
+		}
+
+		ref := referencePattern.FindStringSubmatch(match)[1]
+
+		var resolved string
+
+		resolved, err = r.resolve(ref, stack)
+
+		return resolved
+	})
+
+	return value, err
+}
+
+// resolve returns the fully substituted value of the referenced parameter.
+func (r *resolver) resolve(ref string, stack []string) (string, error) {
+	for _, name := range stack {
+		if name == ref {
+			return "", fmt.Errorf("parameter reference cycle: %s -> %s", strings.Join(stack, " -> "), ref)
+		}
+	}
+
+	if len(stack) > maxReferenceDepth {
+		return "", fmt.Errorf("parameter references nested deeper than %d: %s", maxReferenceDepth, strings.Join(stack, " -> "))
+	}
+
+	value, ok := r.values[ref]
+
+	if !ok {
+		// ref may carry a version or label selector, which GetParameter
+		// understands as is
+		result, err := r.client.GetParameter(r.ctx, &ssm.GetParameterInput{
+			Name:           aws.String(ref),
+			WithDecryption: aws.Bool(r.cfg.WithDecryption),
+		})
+
+		if err != nil {
+			return "", fmt.Errorf("resolving reference %s in %s: %w", ref, stack[len(stack)-1], err)
+		}
+
+		value = aws.ToString(result.Parameter.Value)
+		r.values[ref] = value
+	}
+
+	return r.substitute(value, append(stack, ref))
+}
@@ -0,0 +1,53 @@
+package paramstore
+
+import (
+	"context"
+	"errors"
+	"net"
+
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+)
+
+// isRegionFailure reports whether err suggests the region itself is
+// unavailable: a network error or a 5xx response that outlasted the retries.
+// Cancellations and timeouts of the read itself don't count, even though
+// context.DeadlineExceeded is a net.Error.
+func isRegionFailure(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var netErr net.Error
+
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	var respErr *awshttp.ResponseError
+
+	return errors.As(err, &respErr) && respErr.HTTPStatusCode() >= 500
+}
+
+type regionKey struct{}
+
+// withRegion marks ctx as reading from the fallback region.
+func withRegion(ctx context.Context, region string) context.Context {
+	return context.WithValue(ctx, regionKey{}, region)
+}
+
+// sourceRegion describes the region a read with ctx talks to for error
+// messages: a fallback region or the client's region.
+func (ps *ParamStore) sourceRegion(ctx context.Context) string {
+	if region, ok := ctx.Value(regionKey{}).(string); ok {
+		return region
+	}
+
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	if ps.awsConfig.Region == "" {
+		return "the default region"
+	}
+
+	return ps.awsConfig.Region
+}
@@ -0,0 +1,167 @@
+package paramstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+func TestIsRegionFailure(t *testing.T) {
+	for _, tc := range []struct {
+		err  error
+		want bool
+	}{
+		{context.DeadlineExceeded, false},
+		{fmt.Errorf("operation error: %w", context.DeadlineExceeded), false},
+		{context.Canceled, false},
+		{sdkError("InternalServerError", 503), true},
+		{sdkError("AccessDeniedException", 400), false},
+	} {
+		if got := isRegionFailure(tc.err); got != tc.want {
+			t.Errorf("isRegionFailure(%v) = %v, want %v", tc.err, got, tc.want)
+		}
+	}
+}
+
+// regionServer fails the requests signed for the primary region with
+// status, after waiting for delay.
+func regionServer(t *testing.T, status int, delay time.Duration) *ssmServer {
+	srv := newSSMServer(t, newFakeSSM("/app/a", "1"))
+
+	srv.hook = func(w http.ResponseWriter, r *http.Request) bool {
+		if signingRegion(r) != "us-east-1" {
+			return false
+		}
+
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+		}
+
+		w.WriteHeader(status)
+		w.Write([]byte(`{"__type":"InternalServerError"}`))
+
+		return true
+	}
+
+	return srv
+}
+
+func TestFallbackRegions(t *testing.T) {
+	srv := regionServer(t, http.StatusServiceUnavailable, 0)
+
+	cfg := srv.config("/app")
+	cfg.FallbackRegions = []string{"eu-west-1"}
+	cfg.Retryer = aws.NopRetryer{}
+
+	ps := Provider(cfg, nil)
+
+	if _, err := ps.Read(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := ps.Region(); got != "eu-west-1" {
+		t.Fatalf("got region %s, want the fallback", got)
+	}
+
+	ps.Reset()
+
+	if got := ps.Region(); got != "us-east-1" {
+		t.Fatalf("got region %s after Reset, want the primary", got)
+	}
+}
+
+func TestFallbackErrorNamesFallbackRegion(t *testing.T) {
+	srv := regionServer(t, http.StatusServiceUnavailable, 0)
+
+	// Fail the fallback region as well
+	hook := srv.hook
+	srv.hook = func(w http.ResponseWriter, r *http.Request) bool {
+		if signingRegion(r) == "eu-west-1" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(`{"__type":"InternalServerError"}`))
+
+			return true
+		}
+
+		return hook(w, r)
+	}
+
+	cfg := srv.config("/app")
+	cfg.Paths = PathSpecs(true, "/other")
+	cfg.FallbackRegions = []string{"eu-west-1"}
+	cfg.Retryer = aws.NopRetryer{}
+
+	_, err := Provider(cfg, nil).Read()
+
+	if err == nil || !strings.Contains(err.Error(), "reading /app in eu-west-1") {
+		t.Fatalf("got %v, want the fallback region named", err)
+	}
+}
+
+func TestOperationTimeoutCoversFallbacks(t *testing.T) {
+	srv := regionServer(t, http.StatusServiceUnavailable, time.Second)
+
+	cfg := srv.config("/app")
+	cfg.FallbackRegions = []string{"eu-west-1"}
+	cfg.OperationTimeout = 50 * time.Millisecond
+	cfg.Retryer = aws.NopRetryer{}
+
+	start := time.Now()
+	_, err := Provider(cfg, nil).Read()
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got %v, want a deadline error", err)
+	}
+
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("read took %s", elapsed)
+	}
+
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+
+	for _, req := range srv.requests {
+		if signingRegion(req.Request) == "eu-west-1" {
+			t.Fatal("timed out read fell back to eu-west-1")
+		}
+	}
+}
+
+func TestRegionResolver(t *testing.T) {
+	srv := newSSMServer(t, newFakeSSM("/app/a", "1"))
+
+	cfg := srv.config("/app")
+	cfg.AWSRegion = "us-east-1"
+	cfg.RegionResolver = func() (string, error) {
+		return "ap-southeast-2", nil
+	}
+
+	ps := Provider(cfg, nil)
+
+	if ps == nil {
+		t.Fatal("Provider failed")
+	}
+
+	if _, err := ps.Read(); err != nil {
+		t.Fatal(err)
+	}
+
+	if region := signingRegion(srv.last().Request); region != "ap-southeast-2" {
+		t.Fatalf("signed for %s, want the resolved region", region)
+	}
+
+	cfg.RegionResolver = func() (string, error) {
+		return "", errors.New("no metadata")
+	}
+
+	if Provider(cfg, nil) != nil {
+		t.Fatal("got a provider although the region couldn't be resolved")
+	}
+}
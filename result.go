@@ -0,0 +1,72 @@
+package paramstore
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+// Result bundles the output of a single read.
+type Result struct {
+	// Map is the unflattened config, as returned by Read.
+	Map map[string]interface{}
+	// Flat maps the transformed, delimiter-joined keys to their values.
+	Flat     map[string]interface{}
+	Metadata []ParamMeta
+	Count    int
+	// LastModified is the most recent modification time of all parameters.
+	LastModified time.Time
+	// Checksum is the same hash Checksum returns. It is empty if the values
+	// can't be JSON-encoded.
+	Checksum string
+	ReadAt   time.Time
+	Duration time.Duration
+	// sources maps the keys of Flat read from SSM to their parameter
+	sources map[string]types.Parameter
+	// pages is the number of GetParametersByPath pages the read needed
+	pages int
+}
+
+func newResult(params []types.Parameter, described map[string]types.ParameterMetadata, flat map[string]interface{}, start time.Time) *Result {
+	res := &Result{
+		Flat:     flat,
+		Metadata: buildMetadata(params, described),
+		Count:    len(params),
+		ReadAt:   start,
+		Duration: time.Since(start),
+	}
+
+	for _, meta := range res.Metadata {
+		if meta.LastModified.After(res.LastModified) {
+			res.LastModified = meta.LastModified
+		}
+	}
+
+	return res
+}
+
+// config unflattens res.Flat, wrapping the values in a Leaf if
+// cfg.LeafMetadata is set.
+func (res *Result) config(cfg Config) map[string]interface{} {
+	if cfg.LeafMetadata {
+		return unflatten(cfg, withLeaves(res.Flat, res.sources))
+	}
+
+	return unflatten(cfg, res.Flat)
+}
+
+// ReadResult reads like Read, but returns the values together with their
+// metadata, checksum and timing in a single Result.
+func (ps *ParamStore) ReadResult() (*Result, error) {
+	res, cfg, err := ps.read(context.Background(), nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	res.Map = res.config(cfg)
+	res.Checksum, _ = checksum(res.Flat)
+
+	return res, nil
+}
@@ -0,0 +1,83 @@
+package paramstore
+
+import (
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+)
+
+// RetryJitter selects how the delay between retried SSM calls is randomized.
+type RetryJitter string
+
+const (
+	// RetryJitterFull waits a random duration between zero and the
+	// exponential backoff, spreading out retries of many instances failing
+	// at once. This is the default and matches the SDK.
+	RetryJitterFull RetryJitter = ""
+	// RetryJitterNone waits the exponential backoff itself: 1s, 2s, 4s and
+	// so on, capped at RetryMaxDelay.
+	RetryJitterNone RetryJitter = "none"
+)
+
+// newRetryer returns a retryer constructor reflecting the retry options in
+// cfg, or nil if the SDK defaults should be used.
+func newRetryer(cfg Config) func() aws.Retryer {
+	if cfg.IsRetryable == nil && cfg.RetryJitter == RetryJitterFull && cfg.RetryMaxDelay <= 0 && cfg.RetryMode == "" {
+		return nil
+	}
+
+	standard := func(o *retry.StandardOptions) {
+		// The first classifier with a definite answer wins, so the custom one
+		// only answers for the errors it adds and leaves the rest to the
+		// defaults
+		if cfg.IsRetryable != nil {
+			o.Retryables = append([]retry.IsErrorRetryable{
+				retry.IsErrorRetryableFunc(func(err error) aws.Ternary {
+					if cfg.IsRetryable(err) {
+						return aws.TrueTernary
+					}
+
+					return aws.UnknownTernary
+				}),
+			}, o.Retryables...)
+		}
+
+		if cfg.RetryMaxDelay > 0 {
+			o.MaxBackoff = cfg.RetryMaxDelay
+		}
+
+		if cfg.RetryJitter == RetryJitterNone {
+			o.Backoff = exponentialBackoff{max: o.MaxBackoff}
+		}
+	}
+
+	return func() aws.Retryer {
+		if cfg.RetryMode == aws.RetryModeAdaptive {
+			return retry.NewAdaptiveMode(func(o *retry.AdaptiveModeOptions) {
+				o.StandardOptions = append(o.StandardOptions, standard)
+			})
+		}
+
+		return retry.NewStandard(standard)
+	}
+}
+
+// exponentialBackoff doubles the delay with every attempt, without jitter.
+type exponentialBackoff struct {
+	max time.Duration
+}
+
+// BackoffDelay implements retry.BackoffDelayer.
+func (b exponentialBackoff) BackoffDelay(attempt int, _ error) (time.Duration, error) {
+	if attempt < 1 {
+		attempt = 1
+	}
+
+	// Guard against overflowing the shift
+	if attempt > 32 {
+		return b.max, nil
+	}
+
+	return min(time.Second<<(attempt-1), b.max), nil
+}
@@ -0,0 +1,104 @@
+package paramstore
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+)
+
+func TestRetryMode(t *testing.T) {
+	srv := newSSMServer(t, newFakeSSM())
+
+	for _, mode := range []aws.RetryMode{aws.RetryModeStandard, aws.RetryModeAdaptive} {
+		t.Run(string(mode), func(t *testing.T) {
+			cfg := srv.config("/app")
+			cfg.RetryMode = mode
+
+			ps := Provider(cfg, nil)
+
+			if ps == nil {
+				t.Fatal("Provider failed")
+			}
+
+			retryer := ps.awsConfig.Retryer()
+
+			switch mode {
+			case aws.RetryModeAdaptive:
+				if _, ok := retryer.(*retry.AdaptiveMode); !ok {
+					t.Fatalf("got retryer %T, want adaptive", retryer)
+				}
+			default:
+				if _, ok := retryer.(*retry.Standard); !ok {
+					t.Fatalf("got retryer %T, want standard", retryer)
+				}
+			}
+		})
+	}
+
+	cfg := srv.config("/app")
+	cfg.RetryMode = "eager"
+
+	if _, err := ProviderWithClient(cfg, nil, newFakeSSM()).Read(); err == nil {
+		t.Fatal("accepted an unknown retry mode")
+	}
+}
+
+func TestRetryDelays(t *testing.T) {
+	maxDelay := 3 * time.Second
+	throttled := errors.New("throttled")
+
+	for name, jitter := range map[string]RetryJitter{"full": RetryJitterFull, "none": RetryJitterNone} {
+		t.Run(name, func(t *testing.T) {
+			retryer := newRetryer(Config{RetryJitter: jitter, RetryMaxDelay: maxDelay})()
+
+			for attempt := 1; attempt <= 6; attempt++ {
+				// The SDK's full jitter draws from up to twice the plain backoff
+				backoff := min(time.Second<<(attempt-1), maxDelay)
+				bound := backoff
+
+				if jitter == RetryJitterFull {
+					bound = min(time.Second<<attempt, maxDelay)
+				}
+
+				for i := 0; i < 20; i++ {
+					delay, err := retryer.RetryDelay(attempt, throttled)
+
+					if err != nil {
+						t.Fatal(err)
+					}
+
+					if delay < 0 || delay > bound || (jitter == RetryJitterNone && delay != backoff) {
+						t.Fatalf("attempt %d waited %s, want at most %s", attempt, delay, bound)
+					}
+				}
+			}
+		})
+	}
+
+	if _, err := ProviderWithClient(Config{Path: "/app", RetryJitter: "half"}, nil, newFakeSSM()).Read(); err == nil {
+		t.Fatal("accepted an unknown retry jitter")
+	}
+}
+
+func TestIsRetryableKeepsDefaults(t *testing.T) {
+	kms := errors.New("KMS key pending import")
+
+	retryer := newRetryer(Config{IsRetryable: func(err error) bool {
+		return errors.Is(err, kms)
+	}})()
+
+	if !retryer.IsErrorRetryable(kms) {
+		t.Fatal("the hook's error isn't retried")
+	}
+
+	if !retryer.IsErrorRetryable(sdkError("ThrottlingException", 400)) {
+		t.Fatal("throttling isn't retried with a hook set")
+	}
+
+	if retryer.IsErrorRetryable(sdkError("AccessDeniedException", 400)) {
+		t.Fatal("access denied is retried")
+	}
+}
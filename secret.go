@@ -0,0 +1,54 @@
+package paramstore
+
+import "encoding/json"
+
+// SecretString holds a decrypted SecureString value. It formats and
+// marshals as "[REDACTED]" so it can't leak into logs by accident; the value
+// itself is only available through Reveal.
+type SecretString string
+
+// Reveal returns the secret value.
+func (s SecretString) Reveal() string {
+	return string(s)
+}
+
+func (s SecretString) String() string {
+	return redacted
+}
+
+func (s SecretString) GoString() string {
+	return redacted
+}
+
+func (s SecretString) MarshalJSON() ([]byte, error) {
+	return json.Marshal(redacted)
+}
+
+// RevealSecret returns the plain value of v if it is a SecretString or a
+// string, e.g. as returned by koanf's Get for a wrapped secret.
+func RevealSecret(v interface{}) (string, bool) {
+	switch s := v.(type) {
+	case SecretString:
+		return s.Reveal(), true
+	case string:
+		return s, true
+	default:
+		return "", false
+	}
+}
+
+// revealSecrets returns a copy of a flat map with all SecretString values
+// replaced by their plain values.
+func revealSecrets(flat map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(flat))
+
+	for key, value := range flat {
+		if secret, ok := value.(SecretString); ok {
+			value = secret.Reveal()
+		}
+
+		out[key] = value
+	}
+
+	return out
+}
@@ -0,0 +1,43 @@
+package paramstore
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/smithy-go"
+)
+
+// ErrClockSkew is wrapped into errors caused by the local clock being too far
+// off for SigV4 signatures to be accepted.
+var ErrClockSkew = errors.New("request rejected because of clock skew")
+
+// isClockSkew reports whether err is a signature error caused by clock skew.
+func isClockSkew(err error) bool {
+	var apiErr smithy.APIError
+
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+
+	switch apiErr.ErrorCode() {
+	case "RequestTimeTooSkewed", "RequestExpired", "RequestInTheFuture":
+		return true
+	case "InvalidSignatureException":
+		msg := strings.ToLower(apiErr.ErrorMessage())
+
+		return strings.Contains(msg, "signature expired") || strings.Contains(msg, "signature not yet current")
+	}
+
+	return false
+}
+
+// wrapClockSkew wraps clock skew errors in ErrClockSkew, with a hint on how to
+// fix them. Other errors are returned as is.
+func wrapClockSkew(err error) error {
+	if err == nil || !isClockSkew(err) {
+		return err
+	}
+
+	return fmt.Errorf("%w, check that the system clock is synchronized, e.g. via NTP: %w", ErrClockSkew, err)
+}
@@ -0,0 +1,81 @@
+package paramstore
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestClockSkew(t *testing.T) {
+	srv := newSSMServer(t, newFakeSSM("/app/a", "1"))
+
+	// Answer like SSM does for a request signed too long ago
+	srv.hook = func(w http.ResponseWriter, r *http.Request) bool {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"__type":"InvalidSignatureException","message":"Signature expired: 20240101T000000Z is now earlier than 20240101T000500Z (20240101T001000Z - 5 min.)"}`))
+
+		return true
+	}
+
+	ps := Provider(srv.config("/app"), nil)
+
+	if ps == nil {
+		t.Fatal("Provider failed")
+	}
+
+	if _, err := ps.Read(); !errors.Is(err, ErrClockSkew) {
+		t.Fatalf("got %v, want ErrClockSkew", err)
+	}
+
+	// Watch ticks report it too
+	fake := newFakeSSM("/app/a", "1")
+	ps = ProviderWithClient(Config{Path: "/app", WatchInterval: 5 * time.Millisecond}, nil, fake)
+
+	if _, err := ps.Read(); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	rec := &watchRecorder{}
+
+	if err := ps.WatchContext(ctx, rec.cb); err != nil {
+		t.Fatal(err)
+	}
+
+	fake.setErr(sdkError("RequestTimeTooSkewed", 403))
+
+	waitFor(t, func() bool {
+		return len(rec.errors()) > 0
+	})
+
+	if err := rec.errors()[0]; !errors.Is(err, ErrClockSkew) {
+		t.Fatalf("got %v, want ErrClockSkew", err)
+	}
+}
+
+func TestReadRawClockSkew(t *testing.T) {
+	fake := newFakeSSM("/app/a", "1")
+	fake.setErr(sdkError("RequestTimeTooSkewed", 403))
+
+	ps := ProviderWithClient(Config{Path: "/app"}, nil, fake)
+
+	if _, err := ps.ReadRaw(); !errors.Is(err, ErrClockSkew) {
+		t.Fatalf("got %v, want ErrClockSkew", err)
+	}
+}
+
+func TestOtherErrorsAreNotClockSkew(t *testing.T) {
+	for _, err := range []error{
+		sdkError("AccessDeniedException", 400),
+		sdkError("InvalidSignatureException", 400),
+		errors.New("RequestTimeTooSkewed"),
+	} {
+		if errors.Is(wrapClockSkew(err), ErrClockSkew) {
+			t.Errorf("%v wrapped as ErrClockSkew", err)
+		}
+	}
+}
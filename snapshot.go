@@ -0,0 +1,77 @@
+package paramstore
+
+import (
+	"context"
+	"time"
+)
+
+// Snapshot is the complete state of a successful read. It is a copy, so it
+// stays the same when the provider reads again; compare two snapshots with
+// Diff(old.Flat, new.Flat).
+type Snapshot struct {
+	// Map is the unflattened config, as returned by Read.
+	Map map[string]interface{}
+	// Flat maps the transformed, delimiter-joined keys to their values.
+	Flat     map[string]interface{}
+	Metadata []ParamMeta
+	// Checksum is the same hash Checksum returns. It is empty if the values
+	// can't be JSON-encoded.
+	Checksum string
+	ReadAt   time.Time
+	// Pages is the number of GetParametersByPath pages the read needed.
+	Pages int
+}
+
+// Snapshot returns the state of the last successful read, including the
+// re-reads of Watch with WatchRefresh, without calling SSM. It returns nil if
+// nothing has been read yet.
+func (ps *ParamStore) Snapshot() *Snapshot {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	if ps.flat == nil {
+		return nil
+	}
+
+	return newSnapshot(ps.config, ps.flat, buildMetadata(ps.params, ps.described), ps.readAt, ps.pages)
+}
+
+// ReadDeltaFrom reads like Read and returns the new state together with the
+// changes since prev, the pull-based counterpart of Watch for jobs that
+// reconcile config periodically. A nil prev reports every key as added.
+func (ps *ParamStore) ReadDeltaFrom(ctx context.Context, prev *Snapshot) (*Snapshot, []Change, error) {
+	res, cfg, err := ps.read(ctx, nil)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	snap := newSnapshot(cfg, res.Flat, res.Metadata, res.ReadAt, res.pages)
+
+	var old map[string]interface{}
+
+	if prev != nil {
+		old = prev.Flat
+	}
+
+	return snap, Diff(old, snap.Flat), nil
+}
+
+// newSnapshot builds a snapshot from a copy of flat.
+func newSnapshot(cfg Config, flat map[string]interface{}, metadata []ParamMeta, readAt time.Time, pages int) *Snapshot {
+	snap := &Snapshot{
+		Flat:     make(map[string]interface{}, len(flat)),
+		Metadata: metadata,
+		ReadAt:   readAt,
+		Pages:    pages,
+	}
+
+	for key, value := range flat {
+		snap.Flat[key] = value
+	}
+
+	snap.Map = unflatten(cfg, snap.Flat)
+	snap.Checksum, _ = checksum(snap.Flat)
+
+	return snap
+}
@@ -0,0 +1,44 @@
+package paramstore
+
+import (
+	"sort"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+// SortBy selects the order in which read parameters are transformed and
+// listed by Metadata.
+type SortBy string
+
+const (
+	// SortByName orders parameters by name. This is the default.
+	SortByName SortBy = ""
+	// SortByLastModified orders parameters by modification time, ties
+	// broken by name.
+	SortByLastModified SortBy = "lastModified"
+)
+
+// sortParams orders params in place according to cfg.SortBy and
+// cfg.SortDescending.
+func sortParams(params []types.Parameter, cfg Config) {
+	less := func(a, b types.Parameter) bool {
+		if cfg.SortBy == SortByLastModified {
+			at, bt := aws.ToTime(a.LastModifiedDate), aws.ToTime(b.LastModifiedDate)
+
+			if !at.Equal(bt) {
+				return at.Before(bt)
+			}
+		}
+
+		return aws.ToString(a.Name) < aws.ToString(b.Name)
+	}
+
+	sort.Slice(params, func(i, j int) bool {
+		if cfg.SortDescending {
+			return less(params[j], params[i])
+		}
+
+		return less(params[i], params[j])
+	})
+}
@@ -0,0 +1,29 @@
+package paramstore
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+func TestTransformerCalledInNameOrder(t *testing.T) {
+	fake := newFakeSSM("/app/a", "1", "/app/b", "2", "/app/c", "3")
+	a, b, c := fake.params["/app/a"], fake.params["/app/b"], fake.params["/app/c"]
+	fake.pages = [][]types.Parameter{{c, a}, {b}}
+
+	var order []string
+
+	cb := func(key string) string {
+		order = append(order, key)
+
+		return key
+	}
+
+	if _, err := ProviderWithClient(Config{Path: "/app"}, cb, fake).Read(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(order) != 3 || order[0] != "/app/a" || order[1] != "/app/b" || order[2] != "/app/c" {
+		t.Fatalf("transformer called for %q, want name order", order)
+	}
+}
@@ -0,0 +1,57 @@
+package paramstore
+
+import (
+	"context"
+	"errors"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+// ReadStream pages through the parameters below Path and calls fn for each
+// one as its page arrives, with its full SSM name, its value (trimmed if
+// TrimSpace is set) and the metadata GetParametersByPath returns, without
+// ever holding the whole tree in memory. If fn returns an error, no further
+// pages are requested and that error is returned.
+//
+// Only the path is streamed: DiscoverTags, the decryption fallbacks,
+// DecryptPredicate and ResolveReferences need the full set of parameters and
+// are ignored, as are the transformer and all key handling. Paging works as
+// in Read: OperationTimeout, BeforeRead, the Tracer ("paramstore.ReadStream"),
+// the rate limit, MaxDepth, NameBeginsWith, MaxPages, MaxParameters and the
+// dropping of duplicate parameters across pages all apply. The read baseline
+// isn't updated.
+func (ps *ParamStore) ReadStream(ctx context.Context, fn func(name string, value string, meta ParamMeta) error) error {
+	cfg, client, err := ps.snapshot()
+
+	if err != nil {
+		return err
+	}
+
+	if err := validate(cfg); err != nil {
+		return err
+	}
+
+	if cfg.Path == "" {
+		return errors.New("streaming requires a parameter path")
+	}
+
+	return readOperation(ctx, cfg, "paramstore.ReadStream", func(ctx context.Context) (int, error) {
+		input := pathInput(cfg)
+		count := 0
+
+		err := eachPage(ctx, cfg, client, &input, func(params []types.Parameter) error {
+			for _, param := range filterName(filterDepth(params, cfg), cfg) {
+				if err := fn(aws.ToString(param.Name), rawValue(cfg, param), paramMeta(param)); err != nil {
+					return err
+				}
+
+				count++
+			}
+
+			return nil
+		})
+
+		return count, err
+	})
+}
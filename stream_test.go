@@ -0,0 +1,91 @@
+package paramstore
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+// testTracer records the names of the spans it starts.
+type testTracer struct {
+	spans []string
+}
+
+func (t *testTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	t.spans = append(t.spans, name)
+
+	return ctx, testSpan{}
+}
+
+type testSpan struct{}
+
+func (testSpan) SetAttribute(string, interface{}) {}
+func (testSpan) RecordError(error)                {}
+func (testSpan) End()                             {}
+
+func TestReadStreamPagesLikeRead(t *testing.T) {
+	fake := newFakeSSM("/app/a", "1", "/app/b", "2")
+	a, b := fake.params["/app/a"], fake.params["/app/b"]
+	fake.pages = [][]types.Parameter{{a, b}, {a}}
+
+	tracer := &testTracer{}
+	prepared := false
+
+	ps := ProviderWithClient(Config{
+		Path:   "/app",
+		Tracer: tracer,
+		BeforeRead: func(ctx context.Context) (context.Context, error) {
+			prepared = true
+
+			return ctx, nil
+		},
+	}, nil, fake)
+
+	var names []string
+
+	err := ps.ReadStream(context.Background(), func(name, value string, meta ParamMeta) error {
+		names = append(names, name)
+
+		return nil
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The duplicate on the second page must be dropped
+	if len(names) != 2 || names[0] != "/app/a" || names[1] != "/app/b" {
+		t.Fatalf("got %q, want /app/a and /app/b once", names)
+	}
+
+	if !prepared {
+		t.Fatal("BeforeRead wasn't called")
+	}
+
+	if len(tracer.spans) != 1 || tracer.spans[0] != "paramstore.ReadStream" {
+		t.Fatalf("got spans %q", tracer.spans)
+	}
+}
+
+func TestReadStreamOperationTimeout(t *testing.T) {
+	ps := ProviderWithClient(Config{
+		Path:             "/app",
+		OperationTimeout: 20 * time.Millisecond,
+		BeforeRead: func(ctx context.Context) (context.Context, error) {
+			<-ctx.Done()
+
+			return ctx, nil
+		},
+	}, nil, newFakeSSM("/app/a", "1"))
+
+	err := ps.ReadStream(context.Background(), func(string, string, ParamMeta) error {
+		return nil
+	})
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got %v, want the OperationTimeout to apply", err)
+	}
+}
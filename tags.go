@@ -0,0 +1,120 @@
+package paramstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+// fetchByTags discovers the parameters matching cfg.DiscoverTags and fetches
+// their values.
+func fetchByTags(ctx context.Context, cfg Config, client SSMClient) ([]types.Parameter, error) {
+	// Build one filter per tag, sorted for stable requests
+	keys := make([]string, 0, len(cfg.DiscoverTags))
+
+	for key := range cfg.DiscoverTags {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	var filters []types.ParameterStringFilter
+
+	for _, key := range keys {
+		filters = append(filters, types.ParameterStringFilter{
+			Key:    aws.String("tag:" + key),
+			Option: aws.String("Equals"),
+			Values: cfg.DiscoverTags[key],
+		})
+	}
+
+	input := ssm.DescribeParametersInput{
+		ParameterFilters: filters,
+		MaxResults:       pageSize(cfg.DescribePageSize, describeParametersMaxResults),
+	}
+
+	// Discover parameter names
+	var names []string
+
+	for {
+		result, err := client.DescribeParameters(ctx, &input)
+
+		if err != nil {
+			return nil, fmt.Errorf("discovering parameters by tag: %w", err)
+		}
+
+		for _, meta := range result.Parameters {
+			names = append(names, aws.ToString(meta.Name))
+		}
+
+		input.NextToken = result.NextToken
+
+		if result.NextToken == nil {
+			break
+		}
+	}
+
+	return getParameters(ctx, cfg, client, names, cfg.WithDecryption)
+}
+
+// getParameters fetches the named parameters with GetParameters in batches.
+// Errors from all batches are joined together. Names SSM reports as invalid,
+// usually parameters deleted since they were listed, are skipped with a
+// warning.
+func getParameters(ctx context.Context, cfg Config, client SSMClient, names []string, decrypt bool) ([]types.Parameter, error) {
+	var params []types.Parameter
+	var errs []error
+
+	for start := 0; start < len(names); start += getParametersBatchSize {
+		end := min(start+getParametersBatchSize, len(names))
+
+		result, err := client.GetParameters(ctx, &ssm.GetParametersInput{
+			Names:          names[start:end],
+			WithDecryption: aws.Bool(decrypt),
+		})
+
+		if err != nil {
+			errs = append(errs, fmt.Errorf("fetching parameters %v: %w", names[start:end], err))
+
+			continue
+		}
+
+		if len(result.InvalidParameters) > 0 {
+			warnf(ctx, cfg, "skipping parameters that no longer exist: %s", strings.Join(result.InvalidParameters, ", "))
+		}
+
+		params = append(params, result.Parameters...)
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	return params, nil
+}
+
+// mergeParameters appends the parameters from extra that aren't already in
+// params, keyed by name, so overlapping sources never yield a parameter
+// twice. Parameters without a name are kept for transform to report.
+func mergeParameters(params, extra []types.Parameter) []types.Parameter {
+	seen := make(map[string]bool, len(params))
+
+	for _, param := range params {
+		seen[aws.ToString(param.Name)] = true
+	}
+
+	for _, param := range extra {
+		if param.Name == nil || !seen[*param.Name] {
+			seen[aws.ToString(param.Name)] = true
+			params = append(params, param)
+		}
+	}
+
+	return params
+}
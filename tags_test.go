@@ -0,0 +1,81 @@
+package paramstore
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+)
+
+func TestReadOverlappingPathsOnce(t *testing.T) {
+	fake := newFakeSSM("/app/name", "n", "/app/prod/db/host", "h", "/app/prod/db/port", "5432")
+
+	ps := ProviderWithClient(Config{Paths: PathSpecs(true, "/app", "/app/prod")}, nil, fake)
+	mp, err := ps.Read()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ps.Count() != 3 {
+		t.Fatalf("got %d parameters, want 3", ps.Count())
+	}
+
+	seen := make(map[string]int)
+
+	for _, meta := range ps.Metadata() {
+		seen[meta.Name]++
+	}
+
+	for name, n := range seen {
+		if n != 1 {
+			t.Fatalf("got %s %d times, want once", name, n)
+		}
+	}
+
+	db := mp["app"].(map[string]interface{})["prod"].(map[string]interface{})["db"].(map[string]interface{})
+
+	if db["host"] != "h" || db["port"] != "5432" {
+		t.Fatalf("got %v", db)
+	}
+
+	if n := fake.count("GetParametersByPath"); n != 2 {
+		t.Fatalf("got %d calls, want one per path", n)
+	}
+}
+
+// deletingSSM deletes a parameter right after DescribeParameters listed it.
+type deletingSSM struct {
+	*fakeSSM
+	name string
+}
+
+func (d deletingSSM) DescribeParameters(ctx context.Context, in *ssm.DescribeParametersInput, opts ...func(*ssm.Options)) (*ssm.DescribeParametersOutput, error) {
+	out, err := d.fakeSSM.DescribeParameters(ctx, in, opts...)
+
+	d.remove(d.name)
+
+	return out, err
+}
+
+func TestDiscoverTagsWarnsAboutDeletedParameters(t *testing.T) {
+	fake := newFakeSSM("/a", "1", "/b", "2")
+
+	ps := ProviderWithClient(Config{DiscoverTags: map[string][]string{"team": {"core"}}}, nil, deletingSSM{fake, "/b"})
+	mp, err := ps.Read()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(mp) != 1 || mp["a"] != "1" {
+		t.Fatalf("got %v, want only a", mp)
+	}
+
+	warnings := ps.Warnings()
+
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "/b") {
+		t.Fatalf("got warnings %q, want /b reported", warnings)
+	}
+}
@@ -0,0 +1,132 @@
+package paramstore
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// maxTemplateDepth bounds how deep templates referring to other templated
+// keys are rendered.
+const maxTemplateDepth = 10
+
+// renderer renders Go templates in flat config values.
+type renderer struct {
+	cfg  Config
+	flat map[string]interface{}
+	// done marks the keys whose value has been rendered already
+	done map[string]bool
+}
+
+// renderTemplates renders every string value of flat containing "{{" as a
+// text/template, in place. Templates see cfg.TemplateData as "." and can
+// call env "NAME" for the environment variables listed in cfg.TemplateEnv and
+// key "other.key" for the rendered value of another key. {{resolve:ssm:...}}
+// references are kept verbatim. Missing data, cycles and chains deeper than
+// maxTemplateDepth are errors naming the offending key.
+func renderTemplates(cfg Config, flat map[string]interface{}) error {
+	r := &renderer{cfg: cfg, flat: flat, done: make(map[string]bool)}
+
+	keys := make([]string, 0, len(flat))
+
+	for key := range flat {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if _, err := r.render(key, nil); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// render returns the rendered value of key. stack holds the chain of keys
+// that led here, for cycle detection.
+func (r *renderer) render(key string, stack []string) (string, error) {
+	for _, k := range stack {
+		if k == key {
+			return "", fmt.Errorf("template reference cycle: %s -> %s", strings.Join(stack, " -> "), key)
+		}
+	}
+
+	if len(stack) > maxTemplateDepth {
+		return "", fmt.Errorf("template references nested deeper than %d: %s", maxTemplateDepth, strings.Join(stack, " -> "))
+	}
+
+	value, ok := r.flat[key]
+
+	if !ok {
+		return "", fmt.Errorf("template in %s refers to unknown key %s", stack[len(stack)-1], key)
+	}
+
+	var raw string
+
+	switch v := value.(type) {
+	case string:
+		raw = v
+	case SecretString:
+		raw = v.Reveal()
+	default:
+		return fmt.Sprint(value), nil
+	}
+
+	if r.done[key] || !strings.Contains(referencePattern.ReplaceAllString(raw, ""), "{{") {
+		return raw, nil
+	}
+
+	stack = append(stack, key)
+
+	// Parameter references aren't template actions, so they are quoted to
+	// render as themselves
+	source := referencePattern.ReplaceAllStringFunc(raw, func(ref string) string {
+		return "{{" + strconv.Quote(ref) + "}}"
+	})
+
+	tmpl, err := template.New(key).Option("missingkey=error").Funcs(template.FuncMap{
+		"env": r.env,
+		"key": func(other string) (string, error) {
+			return r.render(other, stack)
+		},
+	}).Parse(source)
+
+	if err != nil {
+		return "", fmt.Errorf("parsing template of %s: %w", key, err)
+	}
+
+	var b strings.Builder
+
+	if err := tmpl.Execute(&b, r.cfg.TemplateData); err != nil {
+		return "", fmt.Errorf("rendering template of %s: %w", key, err)
+	}
+
+	rendered := b.String()
+
+	// Keep wrapped secrets wrapped
+	if _, ok := value.(SecretString); ok {
+		r.flat[key] = SecretString(rendered)
+	} else {
+		r.flat[key] = rendered
+	}
+
+	r.done[key] = true
+
+	return rendered, nil
+}
+
+// env returns the environment variable name if cfg.TemplateEnv allows it.
+func (r *renderer) env(name string) (string, error) {
+	for _, allowed := range r.cfg.TemplateEnv {
+		if allowed == name {
+			return os.Getenv(name), nil
+		}
+	}
+
+	return "", fmt.Errorf("environment variable %s is not in TemplateEnv", name)
+}
@@ -0,0 +1,96 @@
+package paramstore
+
+import (
+	"strings"
+	"testing"
+)
+
+// readTemplates reads kv rendered with TemplateData {"region": "eu-west-1"}
+// and TemplateEnv env.
+func readTemplates(t *testing.T, env []string, kv ...string) (map[string]interface{}, error) {
+	t.Helper()
+
+	cfg := Config{
+		Path:            "/app",
+		Recursive:       true,
+		Delimiter:       ".",
+		RenderTemplates: true,
+		TemplateData:    map[string]interface{}{"region": "eu-west-1"},
+		TemplateEnv:     env,
+	}
+
+	mp, err := ProviderWithClient(cfg, PathTransformer("/app", "."), newFakeSSM(kv...)).Read()
+
+	return flatten(mp), err
+}
+
+// flatten flattens the nested map Read returns into dotted keys.
+func flatten(mp map[string]interface{}) map[string]interface{} {
+	flat := make(map[string]interface{})
+
+	for key, value := range mp {
+		if nested, ok := value.(map[string]interface{}); ok {
+			for k, v := range flatten(nested) {
+				flat[key+"."+k] = v
+			}
+		} else {
+			flat[key] = value
+		}
+	}
+
+	return flat
+}
+
+func TestRenderTemplates(t *testing.T) {
+	t.Setenv("PARAMSTORE_TEST_STAGE", "prod")
+
+	mp, err := readTemplates(t, []string{"PARAMSTORE_TEST_STAGE"},
+		"/app/api/url", "https://{{.region}}.example.com",
+		"/app/api/health", `{{key "api.url"}}/health`,
+		"/app/stage", `{{env "PARAMSTORE_TEST_STAGE"}}`,
+		"/app/db/host", "{{resolve:ssm:/shared/db/host}}",
+		"/app/db/url", "{{resolve:ssm:/shared/db/host}}-{{.region}}",
+	)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for key, want := range map[string]string{
+		"api.url":    "https://eu-west-1.example.com",
+		"api.health": "https://eu-west-1.example.com/health",
+		"stage":      "prod",
+		"db.host":    "{{resolve:ssm:/shared/db/host}}",
+		"db.url":     "{{resolve:ssm:/shared/db/host}}-eu-west-1",
+	} {
+		if mp[key] != want {
+			t.Errorf("%s = %v, want %q", key, mp[key], want)
+		}
+	}
+}
+
+func TestRenderTemplatesErrors(t *testing.T) {
+	t.Setenv("PARAMSTORE_TEST_SECRET", "s3cr3t")
+
+	for name, tc := range map[string]struct {
+		kv   []string
+		want string
+	}{
+		"env not allowed": {[]string{"/app/leak", `{{env "PARAMSTORE_TEST_SECRET"}}`}, "leak"},
+		"missing data":    {[]string{"/app/url", "{{.zone}}"}, "url"},
+		"unknown key":     {[]string{"/app/a", `{{key "b"}}`}, "a"},
+		"cycle":           {[]string{"/app/a", `{{key "b"}}`, "/app/b", `{{key "a"}}`}, "a -> b -> a"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			mp, err := readTemplates(t, nil, tc.kv...)
+
+			if err == nil {
+				t.Fatalf("got %v, want an error", mp)
+			}
+
+			if !strings.Contains(err.Error(), tc.want) || strings.Contains(err.Error(), "s3cr3t") {
+				t.Fatalf("got %v, want an error naming %s", err, tc.want)
+			}
+		})
+	}
+}
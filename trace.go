@@ -0,0 +1,74 @@
+package paramstore
+
+import "context"
+
+// Tracer starts spans. It is small enough to be adapted to OpenTelemetry or
+// any other tracing library without this package depending on one.
+type Tracer interface {
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span is a single traced operation started by a Tracer.
+type Span interface {
+	SetAttribute(key string, value interface{})
+	RecordError(err error)
+	End()
+}
+
+// readStats collects statistics of a single read across helpers.
+type readStats struct {
+	pages int
+	// decryptionFailures lists the SecureStrings readTolerant skipped
+	decryptionFailures []string
+	// truncated is set when SimulateMaxPages stopped a path early
+	truncated bool
+	// warnings collects the non-fatal problems of the read
+	warnings []string
+	// encrypted holds the names of the SecureStrings the last collect
+	// returned without decrypting them
+	encrypted map[string]bool
+}
+
+type readStatsKey struct{}
+
+// withStats attaches fresh read statistics to ctx.
+func withStats(ctx context.Context) (context.Context, *readStats) {
+	stats := &readStats{}
+
+	return context.WithValue(ctx, readStatsKey{}, stats), stats
+}
+
+// statsFrom returns the read statistics attached to ctx, if any.
+func statsFrom(ctx context.Context) *readStats {
+	stats, _ := ctx.Value(readStatsKey{}).(*readStats)
+
+	return stats
+}
+
+// startSpan starts a span if a tracer is configured.
+func startSpan(ctx context.Context, cfg Config, name string) (context.Context, Span) {
+	if cfg.Tracer == nil {
+		return ctx, nil
+	}
+
+	ctx, span := cfg.Tracer.Start(ctx, name)
+	span.SetAttribute("paramstore.path", cfg.Path)
+
+	return ctx, span
+}
+
+// endSpan records the outcome of an operation and ends its span.
+func endSpan(span Span, pages, count int, err error) {
+	if span == nil {
+		return
+	}
+
+	span.SetAttribute("paramstore.pages", pages)
+	span.SetAttribute("paramstore.parameters", count)
+
+	if err != nil {
+		span.RecordError(err)
+	}
+
+	span.End()
+}
@@ -0,0 +1,59 @@
+package paramstore
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+// ReadSecureStrings reads like Read, but only the SecureString parameters,
+// decrypted regardless of WithDecryption. Path reads add a Type
+// ParameterStringFilter, so other parameters aren't transferred at all;
+// parameters found via DiscoverTags are filtered after fetching. The read
+// baseline isn't updated.
+func (ps *ParamStore) ReadSecureStrings(ctx context.Context) (map[string]interface{}, error) {
+	return ps.readType(ctx, types.ParameterTypeSecureString)
+}
+
+// ReadStrings is like ReadSecureStrings for String parameters, and never
+// decrypts anything.
+func (ps *ParamStore) ReadStrings(ctx context.Context) (map[string]interface{}, error) {
+	return ps.readType(ctx, types.ParameterTypeString)
+}
+
+// readType reads only the parameters of type typ.
+func (ps *ParamStore) readType(ctx context.Context, typ types.ParameterType) (map[string]interface{}, error) {
+	cfg, client, err := ps.snapshot()
+
+	if err != nil {
+		return nil, err
+	}
+
+	// Copy the filters so the provider's config isn't modified
+	cfg.ParameterFilters = append(append([]types.ParameterStringFilter{}, cfg.ParameterFilters...), FilterByType(typ))
+	cfg.WithDecryption = typ == types.ParameterTypeSecureString
+	cfg.AutoDecryptSecureStrings = false
+	// Other types are dropped below, so they can't leak ciphertext
+	cfg.StrictDecryption = false
+
+	ctx, cancel := operationContext(ctx, cfg)
+	defer cancel()
+
+	res, cfg, err := ps.readConfig(withoutBaseline(ctx), cfg, client, func(params []types.Parameter) []types.Parameter {
+		out := params[:0]
+
+		for _, param := range params {
+			if param.Type == typ {
+				out = append(out, param)
+			}
+		}
+
+		return out
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return unflatten(cfg, res.Flat), nil
+}
@@ -0,0 +1,79 @@
+package paramstore
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/knadh/koanf/v2"
+)
+
+// Unmarshal reads the config and decodes the part below path, or all of it if
+// path is empty, into out, a pointer to a struct or map. Struct fields are
+// matched against keys without regard to case, using the "koanf" tag if
+// present, so "DB.Host", "db.host" and "DB.HOST" all fill a field tagged
+// `koanf:"host"` in a struct tagged `koanf:"db"`.
+//
+// If several keys differ only in case, only one of them, chosen arbitrarily,
+// is decoded into a field; sections like "DB" and "db" aren't merged. Set
+// KeyCase to fold such keys together during Read instead, where the last one
+// in SortBy order wins on collisions and a warning is logged. That also makes
+// the map returned by Read safe to look up with normalized keys.
+func (ps *ParamStore) Unmarshal(path string, out interface{}) error {
+	mp, err := ps.Read()
+
+	if err != nil {
+		return err
+	}
+
+	ps.mu.RLock()
+	delim := ps.config.Delimiter
+	ps.mu.RUnlock()
+
+	k := koanf.New(delim)
+
+	if err := k.Load(mapProvider(mp), nil); err != nil {
+		return err
+	}
+
+	return k.UnmarshalWithConf(path, out, koanf.UnmarshalConf{Tag: "koanf"})
+}
+
+// ReadInto reads the config and merges it into k. Parameters from SSM
+// override values already in k, while Defaults only fill in keys that are in
+// neither SSM nor k, so precedence is SSM, then k, then Defaults. Keys are
+// nested with the provider's Delimiter, independent of k's.
+func (ps *ParamStore) ReadInto(k *koanf.Koanf) error {
+	res, cfg, err := ps.read(context.Background(), nil)
+
+	if err != nil {
+		return err
+	}
+
+	flat := make(map[string]interface{}, len(res.Flat))
+
+	for key, value := range res.Flat {
+		flat[key] = value
+	}
+
+	// Don't let defaults override what k already has; sources lists exactly
+	// the keys from SSM
+	for key := range cfg.Defaults {
+		if _, fromSSM := res.sources[key]; !fromSSM && k.Exists(strings.ReplaceAll(key, cfg.Delimiter, k.Delim())) {
+			delete(flat, key)
+		}
+	}
+
+	return k.Load(mapProvider(unflatten(cfg, flat)), nil)
+}
+
+// mapProvider serves an already read config to koanf.
+type mapProvider map[string]interface{}
+
+func (m mapProvider) ReadBytes() ([]byte, error) {
+	return nil, errors.New("mapProvider does not support ReadBytes")
+}
+
+func (m mapProvider) Read() (map[string]interface{}, error) {
+	return m, nil
+}
@@ -0,0 +1,50 @@
+package paramstore
+
+import (
+	"runtime/debug"
+
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/smithy-go/middleware"
+)
+
+// modulePath identifies this package in the user agent.
+const modulePath = "github.com/blablacio/koanf-paramstore"
+
+// userAgentName is the user agent key SSM requests are attributed to.
+const userAgentName = "koanf-paramstore"
+
+// moduleVersion returns the version this package was built at, or "devel"
+// if the build info doesn't tell.
+func moduleVersion() string {
+	info, ok := debug.ReadBuildInfo()
+
+	if !ok {
+		return "devel"
+	}
+
+	if info.Main.Path == modulePath && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+
+	for _, dep := range info.Deps {
+		if dep.Path == modulePath {
+			return dep.Version
+		}
+	}
+
+	return "devel"
+}
+
+// userAgentOptions adds this package and cfg.UserAgentSuffix to the user
+// agent of SSM requests.
+func userAgentOptions(cfg Config) []func(*middleware.Stack) error {
+	opts := []func(*middleware.Stack) error{
+		awsmiddleware.AddUserAgentKeyValue(userAgentName, moduleVersion()),
+	}
+
+	if cfg.UserAgentSuffix != "" {
+		opts = append(opts, awsmiddleware.AddUserAgentKey(cfg.UserAgentSuffix))
+	}
+
+	return opts
+}
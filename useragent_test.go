@@ -0,0 +1,29 @@
+package paramstore
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUserAgent(t *testing.T) {
+	srv := newSSMServer(t, newFakeSSM("/app/a", "1"))
+
+	cfg := srv.config("/app")
+	cfg.UserAgentSuffix = "team-payments"
+
+	ps := Provider(cfg, nil)
+
+	if ps == nil {
+		t.Fatal("Provider failed")
+	}
+
+	if _, err := ps.Read(); err != nil {
+		t.Fatal(err)
+	}
+
+	ua := srv.last().Header.Get("User-Agent")
+
+	if !strings.Contains(ua, userAgentName+"/") || !strings.Contains(ua, " team-payments") {
+		t.Fatalf("got user agent %q, want %s and the suffix", ua, userAgentName)
+	}
+}
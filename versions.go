@@ -0,0 +1,287 @@
+package paramstore
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+// Versions returns the version of every parameter fetched by the last read,
+// keyed by parameter name. The result can be stored and passed to ReadSince
+// later on.
+func (ps *ParamStore) Versions() map[string]int64 {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	versions := make(map[string]int64, len(ps.params))
+
+	for _, param := range ps.params {
+		versions[aws.ToString(param.Name)] = param.Version
+	}
+
+	return versions
+}
+
+// ReadSince reads like Read, but leaves out parameters whose version is below
+// the floor recorded for them in versions. The names of those regressed
+// parameters are returned alongside the config. Parameters missing from
+// versions are always included.
+func (ps *ParamStore) ReadSince(versions map[string]int64) (map[string]interface{}, []string, error) {
+	var regressed []string
+
+	res, cfg, err := ps.read(context.Background(), func(params []types.Parameter) []types.Parameter {
+		out := params[:0]
+
+		for _, param := range params {
+			if floor, ok := versions[aws.ToString(param.Name)]; ok && param.Version < floor {
+				regressed = append(regressed, aws.ToString(param.Name))
+
+				continue
+			}
+
+			out = append(out, param)
+		}
+
+		return out
+	})
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return unflatten(cfg, res.Flat), regressed, nil
+}
+
+// ReadAtVersions reads like Read, but replaces the parameters named in
+// versions with the recorded version, e.g. from an earlier Versions call.
+// Pinned parameters that no longer show up below the path are added back.
+// Every pinned parameter costs an extra GetParameter call, made before the
+// path is read; the read fails if any of them can't be fetched.
+func (ps *ParamStore) ReadAtVersions(versions map[string]int64) (map[string]interface{}, error) {
+	ctx := context.Background()
+
+	cfg, client, err := ps.snapshot()
+
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(versions))
+
+	for name := range versions {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	pinned := make(map[string]types.Parameter, len(names))
+
+	for _, name := range names {
+		result, err := client.GetParameter(ctx, &ssm.GetParameterInput{
+			Name:           aws.String(fmt.Sprintf("%s:%d", name, versions[name])),
+			WithDecryption: aws.Bool(cfg.WithDecryption || cfg.AutoDecryptSecureStrings),
+		})
+
+		if err != nil {
+			return nil, fmt.Errorf("reading %s version %d: %w", name, versions[name], err)
+		}
+
+		param := *result.Parameter
+		param.Name = aws.String(name)
+		pinned[name] = param
+	}
+
+	res, cfg, err := ps.read(ctx, func(params []types.Parameter) []types.Parameter {
+		seen := make(map[string]bool, len(params))
+
+		for i, param := range params {
+			if p, ok := pinned[aws.ToString(param.Name)]; ok {
+				params[i] = p
+				seen[aws.ToString(param.Name)] = true
+			}
+		}
+
+		for _, name := range names {
+			if !seen[name] {
+				params = append(params, pinned[name])
+			}
+		}
+
+		return params
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return unflatten(cfg, res.Flat), nil
+}
+
+const (
+	// consistentReadInitialDelay is the first pause between ReadConsistent
+	// attempts, doubling up to consistentReadMaxDelay.
+	consistentReadInitialDelay = 100 * time.Millisecond
+	consistentReadMaxDelay     = 5 * time.Second
+)
+
+// ReadConsistent reads repeatedly until every parameter in expect is visible
+// with at least the expected version, which is useful right after a write
+// since GetParametersByPath is eventually consistent. Attempts back off
+// exponentially; ctx bounds the total wait. On timeout the error names the
+// parameters that were still stale.
+func (ps *ParamStore) ReadConsistent(ctx context.Context, expect map[string]int64) (map[string]interface{}, error) {
+	delay := consistentReadInitialDelay
+
+	for {
+		res, cfg, err := ps.read(ctx, nil)
+
+		if err != nil {
+			return nil, err
+		}
+
+		stale := staleVersions(res.Metadata, expect)
+
+		if len(stale) == 0 {
+			return unflatten(cfg, res.Flat), nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("waiting for %s: %w", strings.Join(stale, ", "), ctx.Err())
+		case <-time.After(delay):
+		}
+
+		delay = min(delay*2, consistentReadMaxDelay)
+	}
+}
+
+// staleVersions returns the sorted names from expect that are missing from
+// metas or below their expected version.
+func staleVersions(metas []ParamMeta, expect map[string]int64) []string {
+	observed := make(map[string]int64, len(metas))
+
+	for _, meta := range metas {
+		observed[meta.Name] = meta.Version
+	}
+
+	var stale []string
+
+	for name, version := range expect {
+		if got, ok := observed[name]; !ok || got < version {
+			stale = append(stale, name)
+		}
+	}
+
+	sort.Strings(stale)
+
+	return stale
+}
+
+// ChangeToken returns a token derived from the names and versions of the
+// parameters fetched by the last read. It changes whenever a parameter is
+// added, removed or updated, and can be passed to ReadIfChanged.
+func (ps *ParamStore) ChangeToken() string {
+	return changeToken(ps.Versions())
+}
+
+// ReadIfChanged returns the config and the new change token if the parameters
+// differ from what prevToken describes. Changes are detected by describing
+// the parameters, so values are only fetched and decrypted when something
+// changed. When nothing changed, the map is nil and changed is false.
+//
+// DescribeParameters can't apply Label filters or tag discovery, and only
+// Path is described, so with either of those or with Paths a full read is
+// done every time and only its token is compared. Parameters skipped by a
+// tolerant read make the tokens differ, which also results in a full read.
+func (ps *ParamStore) ReadIfChanged(prevToken string) (map[string]interface{}, string, bool, error) {
+	ctx := context.Background()
+
+	cfg, client, err := ps.snapshot()
+
+	if err != nil {
+		return nil, "", false, err
+	}
+
+	// Check the metadata first when it can match the read
+	if cfg.Path != "" && len(cfg.Paths) == 0 && len(cfg.DiscoverTags) == 0 && !hasLabelFilter(cfg) {
+		metas, err := describeParameters(ctx, cfg, client)
+
+		if err != nil {
+			return nil, "", false, err
+		}
+
+		params := make([]types.Parameter, 0, len(metas))
+
+		for _, meta := range metas {
+			params = append(params, types.Parameter{Name: meta.Name, Version: meta.Version})
+		}
+
+		versions := make(map[string]int64, len(params))
+
+		for _, param := range filterDepth(params, cfg) {
+			versions[aws.ToString(param.Name)] = param.Version
+		}
+
+		if token := changeToken(versions); token == prevToken {
+			return nil, token, false, nil
+		}
+	}
+
+	res, cfg, err := ps.read(ctx, nil)
+
+	if err != nil {
+		return nil, "", false, err
+	}
+
+	versions := make(map[string]int64, len(res.Metadata))
+
+	for _, meta := range res.Metadata {
+		versions[meta.Name] = meta.Version
+	}
+
+	token := changeToken(versions)
+
+	if token == prevToken {
+		return nil, token, false, nil
+	}
+
+	return unflatten(cfg, res.Flat), token, true, nil
+}
+
+// hasLabelFilter reports whether cfg filters parameters by label.
+func hasLabelFilter(cfg Config) bool {
+	for _, f := range cfg.ParameterFilters {
+		if aws.ToString(f.Key) == "Label" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// changeToken hashes the sorted name and version pairs of versions.
+func changeToken(versions map[string]int64) string {
+	names := make([]string, 0, len(versions))
+
+	for name := range versions {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	h := sha256.New()
+
+	for _, name := range names {
+		fmt.Fprintf(h, "%s\x00%d\n", name, versions[name])
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
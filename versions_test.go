@@ -0,0 +1,45 @@
+package paramstore
+
+import "testing"
+
+func TestReadIfChanged(t *testing.T) {
+	for name, cfg := range map[string]Config{
+		"path":  {Path: "/app"},
+		"paths": {Path: "/app", Paths: PathSpecs(false, "/other")},
+	} {
+		t.Run(name, func(t *testing.T) {
+			fake := newFakeSSM("/app/a", "1", "/other/b", "2")
+			ps := ProviderWithClient(cfg, nil, fake)
+
+			mp, token, changed, err := ps.ReadIfChanged("")
+
+			if err != nil || !changed || mp == nil {
+				t.Fatalf("got %v, %v, %v, want the first read to report a change", mp, changed, err)
+			}
+
+			reads, describes := fake.count("GetParametersByPath"), fake.count("DescribeParameters")
+
+			mp, next, changed, err := ps.ReadIfChanged(token)
+
+			if err != nil || changed || mp != nil || next != token {
+				t.Fatalf("got %v, %v, %v, want no change", mp, changed, err)
+			}
+
+			reads, describes = fake.count("GetParametersByPath")-reads, fake.count("DescribeParameters")-describes
+
+			// Only Path can be described, so Paths always reads in full
+			switch {
+			case len(cfg.Paths) == 0 && (reads != 0 || describes != 1):
+				t.Fatalf("got %d reads and %d describes, want only a describe", reads, describes)
+			case len(cfg.Paths) > 0 && (reads != 2 || describes != 0):
+				t.Fatalf("got %d reads and %d describes, want only a full read", reads, describes)
+			}
+
+			fake.set("/app/a", "10", "String")
+
+			if _, _, changed, err := ps.ReadIfChanged(token); err != nil || !changed {
+				t.Fatalf("got %v, %v, want a change", changed, err)
+			}
+		})
+	}
+}
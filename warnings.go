@@ -0,0 +1,30 @@
+package paramstore
+
+import (
+	"context"
+	"fmt"
+)
+
+// Warnings returns the non-fatal problems of the last successful read, like
+// SecureStrings skipped by TolerateDecryptionErrors, branches skipped by
+// TolerateAccessDenied, duplicate ARNs, keys several parameters map to and
+// parameters deleted while being read. They are also reported via Logger as
+// they occur.
+func (ps *ParamStore) Warnings() []string {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	return append([]string(nil), ps.warnings...)
+}
+
+// warnf reports a non-fatal problem via cfg.Logger and records it in the
+// read statistics of ctx, if any.
+func warnf(ctx context.Context, cfg Config, format string, v ...interface{}) {
+	msg := fmt.Sprintf(format, v...)
+
+	logf(cfg.Logger, "paramstore: %s", msg)
+
+	if stats := statsFrom(ctx); stats != nil {
+		stats.warnings = append(stats.warnings, msg)
+	}
+}
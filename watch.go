@@ -0,0 +1,280 @@
+package paramstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	"github.com/aws/smithy-go"
+)
+
+// ErrWatchTerminated is passed to the watch callback when Watch gives up
+// after MaxConsecutiveWatchErrors identical errors.
+var ErrWatchTerminated = errors.New("watch terminated")
+
+// tickContext derives the context of a single watch tick from parent.
+func tickContext(parent context.Context, cfg Config) (context.Context, context.CancelFunc) {
+	if cfg.WatchFetchTimeout <= 0 {
+		return context.WithCancel(parent)
+	}
+
+	return context.WithTimeout(parent, cfg.WatchFetchTimeout)
+}
+
+// errorStreak counts how often the same error occurred in a row, and how
+// many ticks failed in a row with any error.
+type errorStreak struct {
+	last     string
+	count    int
+	failures int
+}
+
+// errorKey identifies the kind of err for errorStreak. The messages of SSM
+// errors include a request ID, so they are compared by their error code;
+// other errors by the message of the innermost wrapped error.
+func errorKey(err error) string {
+	var apiErr smithy.APIError
+
+	if errors.As(err, &apiErr) {
+		return apiErr.ErrorCode()
+	}
+
+	for inner := errors.Unwrap(err); inner != nil; inner = errors.Unwrap(err) {
+		err = inner
+	}
+
+	return err.Error()
+}
+
+// WatchHealth describes the outcome of a single watch tick, as passed to
+// Config.ObserveWatch.
+type WatchHealth struct {
+	// ConsecutiveErrors is the number of ticks that failed in a row,
+	// including this one, with any error. It is 0 for successful ticks.
+	ConsecutiveErrors int
+	// Recovered reports a successful tick following failed ones.
+	Recovered bool
+	Err       error
+}
+
+// watchFailed reports err to the callback and tracks it in streak. It returns
+// true, after sending the final ErrWatchTerminated, when the watch should
+// stop.
+func watchFailed(cfg Config, cb func(event interface{}, err error), streak *errorStreak, err error) bool {
+	if key := errorKey(err); key == streak.last {
+		streak.count++
+	} else {
+		streak.last = key
+		streak.count = 1
+	}
+
+	streak.failures++
+
+	if cfg.ObserveWatch != nil {
+		cfg.ObserveWatch(WatchHealth{ConsecutiveErrors: streak.failures, Err: err})
+	}
+
+	if cfg.MaxConsecutiveWatchErrors <= 0 || streak.count < cfg.MaxConsecutiveWatchErrors {
+		notify(cfg, cb, nil, err)
+
+		return false
+	}
+
+	notify(cfg, cb, nil, fmt.Errorf("%w after %d consecutive failures: %w", ErrWatchTerminated, streak.count, err))
+
+	return true
+}
+
+// watchSucceeded resets streak after a successful tick, reporting a recovery
+// from failed ticks to Logger.
+func watchSucceeded(cfg Config, streak *errorStreak) {
+	recovered := streak.failures > 0
+
+	if recovered {
+		logf(cfg.Logger, "paramstore: watch recovered after %d failed ticks", streak.failures)
+	}
+
+	*streak = errorStreak{}
+
+	if cfg.ObserveWatch != nil {
+		cfg.ObserveWatch(WatchHealth{Recovered: recovered})
+	}
+}
+
+// mergePending adds updates to the changes awaiting notification, keeping the
+// newest version of each parameter. It reports whether anything was new.
+func mergePending(pending, updates []types.Parameter) ([]types.Parameter, bool) {
+	var changed bool
+
+	for _, update := range updates {
+		found := false
+
+		for i, p := range pending {
+			if paramID(p) != paramID(update) {
+				continue
+			}
+
+			found = true
+
+			if p.Version != update.Version {
+				pending[i] = update
+				changed = true
+			}
+		}
+
+		if !found {
+			pending = append(pending, update)
+			changed = true
+		}
+	}
+
+	return pending, changed
+}
+
+// unreported drops the updates whose version is already in reported.
+func unreported(reported, updates []types.Parameter) []types.Parameter {
+	var out []types.Parameter
+
+	for _, update := range updates {
+		seen := false
+
+		for _, p := range reported {
+			if paramID(p) == paramID(update) && p.Version == update.Version {
+				seen = true
+			}
+		}
+
+		if !seen {
+			out = append(out, update)
+		}
+	}
+
+	return out
+}
+
+// filterWatched keeps the parameters whose transformed key is in
+// cfg.WatchKeys. Parameters that fail to transform are dropped.
+func (ps *ParamStore) filterWatched(cfg Config, params []types.Parameter) []types.Parameter {
+	watched := make(map[string]bool, len(cfg.WatchKeys))
+
+	for _, key := range cfg.WatchKeys {
+		watched[key] = true
+	}
+
+	var out []types.Parameter
+
+	for _, param := range params {
+		if key, _, err := ps.transform(cfg, param); err == nil && watched[key] {
+			out = append(out, param)
+		}
+	}
+
+	return out
+}
+
+// heartbeat reports a tick without changes as an empty, non-nil event if
+// cfg.WatchHeartbeat is set.
+func heartbeat(cfg Config, cb func(event interface{}, err error)) {
+	if cfg.WatchHeartbeat {
+		notify(cfg, cb, watchEvent(cfg, nil, []types.Parameter{}), nil)
+	}
+}
+
+// ParamChange is a single changed parameter in the Watch events of
+// WatchPrevious. The Old fields are zero for new parameters.
+type ParamChange struct {
+	Name       string
+	Type       types.ParameterType
+	OldValue   string
+	NewValue   string
+	OldVersion int64
+	NewVersion int64
+}
+
+// priorValues maps the IDs of changes awaiting notification to their
+// baseline parameter, which is zero for new ones.
+type priorValues map[string]types.Parameter
+
+// record remembers the baseline version of updates not yet recorded.
+func (p priorValues) record(baseline, updates []types.Parameter) {
+	for _, update := range updates {
+		id := paramID(update)
+
+		if _, ok := p[id]; ok {
+			continue
+		}
+
+		p[id] = types.Parameter{}
+
+		for _, param := range baseline {
+			if paramID(param) == id {
+				p[id] = param
+			}
+		}
+	}
+}
+
+// watchEvent builds the event reporting updates, as []ParamChange with the
+// values recorded in prior if cfg.WatchPrevious is set. Reported updates are
+// forgotten by prior.
+func watchEvent(cfg Config, prior priorValues, updates []types.Parameter) interface{} {
+	if !cfg.WatchPrevious {
+		return updates
+	}
+
+	changes := make([]ParamChange, 0, len(updates))
+
+	for _, update := range updates {
+		id := paramID(update)
+		old := prior[id]
+		delete(prior, id)
+
+		change := ParamChange{
+			Name:       aws.ToString(update.Name),
+			Type:       update.Type,
+			OldValue:   aws.ToString(old.Value),
+			NewValue:   aws.ToString(update.Value),
+			OldVersion: old.Version,
+			NewVersion: update.Version,
+		}
+
+		// Keep secrets out of logged events
+		if !cfg.WatchRevealSecrets && (update.Type == types.ParameterTypeSecureString || old.Type == types.ParameterTypeSecureString) {
+			if change.OldValue != "" {
+				change.OldValue = redacted
+			}
+
+			change.NewValue = redacted
+		}
+
+		changes = append(changes, change)
+	}
+
+	return changes
+}
+
+// notify calls the watch callback, bounded by cfg.WatchCallbackTimeout.
+func notify(cfg Config, cb func(event interface{}, err error), event interface{}, err error) {
+	if cfg.WatchCallbackTimeout <= 0 {
+		cb(event, err)
+
+		return
+	}
+
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		cb(event, err)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(cfg.WatchCallbackTimeout):
+		logf(cfg.Logger, "paramstore: watch callback still running after %s, no longer waiting for it", cfg.WatchCallbackTimeout)
+	}
+}
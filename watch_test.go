@@ -0,0 +1,531 @@
+package paramstore
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+// watchRecorder collects the events and errors passed to a watch callback.
+type watchRecorder struct {
+	mu     sync.Mutex
+	events []interface{}
+	errs   []error
+}
+
+func (r *watchRecorder) cb(event interface{}, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err != nil {
+		r.errs = append(r.errs, err)
+	} else {
+		r.events = append(r.events, event)
+	}
+}
+
+func (r *watchRecorder) errors() []error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]error(nil), r.errs...)
+}
+
+func (r *watchRecorder) eventList() []interface{} {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]interface{}(nil), r.events...)
+}
+
+func TestWatchTerminatesAfterRepeatedErrors(t *testing.T) {
+	fake := newFakeSSM("/app/a", "1")
+	ps := ProviderWithClient(Config{
+		Path:                      "/app",
+		WatchInterval:             5 * time.Millisecond,
+		MaxConsecutiveWatchErrors: 3,
+	}, nil, fake)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	rec := &watchRecorder{}
+
+	if err := ps.WatchContext(ctx, rec.cb); err != nil {
+		t.Fatal(err)
+	}
+
+	// Every failure carries a new request ID, like real SSM errors
+	fake.setErrFunc(func() error {
+		return sdkError("AccessDeniedException", 400)
+	})
+
+	waitFor(t, func() bool {
+		errs := rec.errors()
+
+		return len(errs) > 0 && errors.Is(errs[len(errs)-1], ErrWatchTerminated)
+	})
+
+	// The loop must have stopped
+	time.Sleep(30 * time.Millisecond)
+
+	errs := rec.errors()
+
+	if len(errs) != 3 {
+		t.Fatalf("got %d errors, want 3: %v", len(errs), errs)
+	}
+
+	for _, err := range errs[:2] {
+		if errors.Is(err, ErrWatchTerminated) {
+			t.Fatalf("terminated early: %v", err)
+		}
+	}
+}
+
+func TestWatchErrorStreakResetsOnDifferentError(t *testing.T) {
+	var streak errorStreak
+
+	cfg := Config{MaxConsecutiveWatchErrors: 2}
+	cb := func(interface{}, error) {}
+
+	if watchFailed(cfg, cb, &streak, sdkError("ThrottlingException", 400)) {
+		t.Fatal("stopped after the first error")
+	}
+
+	if watchFailed(cfg, cb, &streak, sdkError("AccessDeniedException", 400)) {
+		t.Fatal("stopped after a different error")
+	}
+
+	if !watchFailed(cfg, cb, &streak, sdkError("AccessDeniedException", 400)) {
+		t.Fatal("didn't stop after the same error code twice")
+	}
+}
+
+// reportedNames returns the names of the parameters in watch events.
+func reportedNames(events []interface{}) map[string]bool {
+	names := make(map[string]bool)
+
+	for _, event := range events {
+		switch event := event.(type) {
+		case []ParamChange:
+			for _, change := range event {
+				names[change.Name] = true
+			}
+		case []types.Parameter:
+			for _, param := range event {
+				names[aws.ToString(param.Name)] = true
+			}
+		}
+	}
+
+	return names
+}
+
+func TestWatchWithoutARNs(t *testing.T) {
+	for name, cfg := range map[string]Config{
+		"previous": {WatchPrevious: true},
+		"debounce": {WatchDebounce: 10 * time.Millisecond},
+	} {
+		t.Run(name, func(t *testing.T) {
+			fake := newFakeSSM("/app/a", "1", "/app/b", "2")
+			fake.omitARN = true
+
+			cfg.Path = "/app"
+			cfg.WatchInterval = 5 * time.Millisecond
+
+			ps := ProviderWithClient(cfg, nil, fake)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			rec := &watchRecorder{}
+
+			if err := ps.WatchContext(ctx, rec.cb); err != nil {
+				t.Fatal(err)
+			}
+
+			fake.set("/app/a", "10", types.ParameterTypeString)
+			fake.set("/app/b", "20", types.ParameterTypeString)
+
+			// Parameters without an ARN must not collapse into one
+			waitFor(t, func() bool {
+				names := reportedNames(rec.eventList())
+
+				return names["/app/a"] && names["/app/b"]
+			})
+
+			if errs := rec.errors(); len(errs) > 0 {
+				t.Fatalf("got errors %v", errs)
+			}
+		})
+	}
+}
+
+func TestWatchWithoutRead(t *testing.T) {
+	fake := newFakeSSM("/app/a", "1", "/app/b", "2")
+	ps := ProviderWithClient(Config{
+		Path:             "/app",
+		WatchInterval:    5 * time.Millisecond,
+		WatchEmitInitial: true,
+	}, nil, fake)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	rec := &watchRecorder{}
+
+	if err := ps.WatchContext(ctx, rec.cb); err != nil {
+		t.Fatal(err)
+	}
+
+	fake.set("/app/a", "10", types.ParameterTypeString)
+
+	waitFor(t, func() bool {
+		return len(rec.eventList()) >= 2
+	})
+
+	events := rec.eventList()
+
+	// The initial event carries the primed baseline
+	if names := reportedNames(events[:1]); !names["/app/a"] || !names["/app/b"] {
+		t.Fatalf("got initial event %v, want the baseline", events[0])
+	}
+
+	// Only the change is reported against it
+	if names := reportedNames(events[1:2]); len(names) != 1 || !names["/app/a"] {
+		t.Fatalf("got event %v, want only /app/a", events[1])
+	}
+
+	if input := fake.lastInput(); aws.ToString(input.Path) != "/app" {
+		t.Fatalf("watched %q, want /app", aws.ToString(input.Path))
+	}
+
+	if errs := rec.errors(); len(errs) > 0 {
+		t.Fatalf("got errors %v", errs)
+	}
+}
+
+// hangingSSM is a fakeSSM whose GetParametersByPath hangs until its context
+// is done once hang is set, and reports when it returned.
+type hangingSSM struct {
+	*fakeSSM
+	hang     atomic.Bool
+	returned chan struct{}
+}
+
+func (h *hangingSSM) GetParametersByPath(ctx context.Context, in *ssm.GetParametersByPathInput, opts ...func(*ssm.Options)) (*ssm.GetParametersByPathOutput, error) {
+	if !h.hang.Load() {
+		return h.fakeSSM.GetParametersByPath(ctx, in, opts...)
+	}
+
+	<-ctx.Done()
+
+	select {
+	case h.returned <- struct{}{}:
+	default:
+	}
+
+	return nil, ctx.Err()
+}
+
+func TestWatchFetchTimeout(t *testing.T) {
+	client := &hangingSSM{fakeSSM: newFakeSSM("/app/a", "1"), returned: make(chan struct{}, 1)}
+	ps := ProviderWithClient(Config{
+		Path:              "/app",
+		WatchInterval:     5 * time.Millisecond,
+		WatchFetchTimeout: 10 * time.Millisecond,
+	}, nil, client)
+
+	if _, err := ps.Read(); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	rec := &watchRecorder{}
+	client.hang.Store(true)
+
+	if err := ps.WatchContext(ctx, rec.cb); err != nil {
+		t.Fatal(err)
+	}
+
+	// Hung ticks fail on their own while the watch keeps going
+	waitFor(t, func() bool {
+		return len(rec.errors()) >= 2
+	})
+
+	if err := rec.errors()[0]; !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got %v, want the tick deadline", err)
+	}
+}
+
+func TestWatchCancelAbortsFetch(t *testing.T) {
+	client := &hangingSSM{fakeSSM: newFakeSSM("/app/a", "1"), returned: make(chan struct{}, 1)}
+	ps := ProviderWithClient(Config{
+		Path:          "/app",
+		WatchInterval: 5 * time.Millisecond,
+	}, nil, client)
+
+	if _, err := ps.Read(); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	rec := &watchRecorder{}
+	client.hang.Store(true)
+
+	if err := ps.WatchContext(ctx, rec.cb); err != nil {
+		t.Fatal(err)
+	}
+
+	// Let a tick get stuck without a tick timeout, then cancel the watch
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+
+	select {
+	case <-client.returned:
+	case <-time.After(time.Second):
+		t.Fatal("cancelling the watch didn't abort the in-flight fetch")
+	}
+
+	// The watch stops quietly
+	time.Sleep(20 * time.Millisecond)
+
+	if errs := rec.errors(); len(errs) > 0 {
+		t.Fatalf("got errors %v after cancelling", errs)
+	}
+}
+
+func TestWatchInputMatchesConfig(t *testing.T) {
+	fake := newFakeSSM("/app/a", "1")
+	filters := []types.ParameterStringFilter{{Key: aws.String("Label"), Option: aws.String("Equals"), Values: []string{"live"}}}
+
+	cfg := Config{
+		Path:             "/app",
+		Recursive:        true,
+		WithDecryption:   true,
+		ParameterFilters: filters,
+		WatchInterval:    5 * time.Millisecond,
+	}
+	ps := ProviderWithClient(cfg, nil, fake)
+
+	if _, err := ps.Read(); err != nil {
+		t.Fatal(err)
+	}
+
+	read := fake.lastInput()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if err := ps.WatchContext(ctx, func(interface{}, error) {}); err != nil {
+		t.Fatal(err)
+	}
+
+	waitFor(t, func() bool {
+		return fake.count("GetParametersByPath") >= 2
+	})
+
+	watched := fake.lastInput()
+	read.NextToken, watched.NextToken = nil, nil
+
+	if !reflect.DeepEqual(watched, read) {
+		t.Fatalf("watched with %+v, read with %+v", watched, read)
+	}
+
+	// Later ticks follow the current config
+	cfg.Recursive = false
+	cfg.ParameterFilters = nil
+
+	if err := ps.UpdateConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	waitFor(t, func() bool {
+		input := fake.lastInput()
+
+		return !aws.ToBool(input.Recursive) && len(input.ParameterFilters) == 0
+	})
+}
+
+func TestWatchPrevious(t *testing.T) {
+	for name, reveal := range map[string]bool{"redacted": false, "revealed": true} {
+		t.Run(name, func(t *testing.T) {
+			fake := newFakeSSM("/app/a", "1")
+			fake.set("/app/secret", "old", types.ParameterTypeSecureString)
+
+			ps := ProviderWithClient(Config{
+				Path:               "/app",
+				WithDecryption:     true,
+				WatchInterval:      5 * time.Millisecond,
+				WatchPrevious:      true,
+				WatchRevealSecrets: reveal,
+			}, nil, fake)
+
+			if _, err := ps.Read(); err != nil {
+				t.Fatal(err)
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			rec := &watchRecorder{}
+
+			if err := ps.WatchContext(ctx, rec.cb); err != nil {
+				t.Fatal(err)
+			}
+
+			fake.set("/app/a", "2", types.ParameterTypeString)
+			fake.set("/app/secret", "new", types.ParameterTypeSecureString)
+
+			changes := make(map[string]ParamChange)
+
+			waitFor(t, func() bool {
+				for _, event := range rec.eventList() {
+					for _, change := range event.([]ParamChange) {
+						changes[change.Name] = change
+					}
+				}
+
+				return len(changes) == 2
+			})
+
+			if got := changes["/app/a"]; got.OldValue != "1" || got.NewValue != "2" || got.NewVersion != got.OldVersion+1 {
+				t.Fatalf("got %+v, want 1 changed to 2", got)
+			}
+
+			want := ParamChange{Name: "/app/secret", Type: types.ParameterTypeSecureString, OldValue: redacted, NewValue: redacted}
+
+			if reveal {
+				want.OldValue, want.NewValue = "old", "new"
+			}
+
+			got := changes["/app/secret"]
+			got.OldVersion, got.NewVersion = 0, 0
+
+			if got != want {
+				t.Fatalf("got %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestObserveWatch(t *testing.T) {
+	fake := newFakeSSM("/app/a", "1")
+
+	var mu sync.Mutex
+	var ticks []WatchHealth
+
+	ps := ProviderWithClient(Config{
+		Path:          "/app",
+		WatchInterval: 5 * time.Millisecond,
+		ObserveWatch: func(health WatchHealth) {
+			mu.Lock()
+			defer mu.Unlock()
+
+			ticks = append(ticks, health)
+		},
+	}, nil, fake)
+
+	if _, err := ps.Read(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Fail three ticks, then recover
+	var failed atomic.Int32
+
+	fake.setErrFunc(func() error {
+		if failed.Add(1) > 3 {
+			return nil
+		}
+
+		return sdkError("ThrottlingException", 400)
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if err := ps.WatchContext(ctx, func(interface{}, error) {}); err != nil {
+		t.Fatal(err)
+	}
+
+	waitFor(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return len(ticks) >= 5
+	})
+
+	cancel()
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	for i, want := range []WatchHealth{
+		{ConsecutiveErrors: 1},
+		{ConsecutiveErrors: 2},
+		{ConsecutiveErrors: 3},
+		{Recovered: true},
+		{},
+	} {
+		got := ticks[i]
+
+		if got.ConsecutiveErrors != want.ConsecutiveErrors || got.Recovered != want.Recovered || (got.Err != nil) != (want.ConsecutiveErrors > 0) {
+			t.Fatalf("tick %d: got %+v, want %+v", i, got, want)
+		}
+	}
+}
+
+func TestWatchHeartbeatWhileDebouncing(t *testing.T) {
+	fake := newFakeSSM("/app/a", "1")
+	ps := ProviderWithClient(Config{
+		Path:           "/app",
+		WatchInterval:  5 * time.Millisecond,
+		WatchDebounce:  60 * time.Millisecond,
+		WatchHeartbeat: true,
+	}, nil, fake)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	rec := &watchRecorder{}
+
+	if err := ps.WatchContext(ctx, rec.cb); err != nil {
+		t.Fatal(err)
+	}
+
+	// Quiet ticks beat
+	waitFor(t, func() bool { return len(rec.eventList()) > 0 })
+
+	before := len(rec.eventList())
+	fake.set("/app/a", "2", types.ParameterTypeString)
+
+	waitFor(t, func() bool { return reportedNames(rec.eventList())["/app/a"] })
+
+	// A tick fetched before the change may still beat, but none may while
+	// the change is held back
+	beats := 0
+
+	for _, event := range rec.eventList()[before:] {
+		if params, ok := event.([]types.Parameter); ok && len(params) > 0 {
+			break
+		}
+
+		beats++
+	}
+
+	if beats > 1 {
+		t.Fatalf("got %d heartbeats while a change was pending", beats)
+	}
+}
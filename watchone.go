@@ -0,0 +1,113 @@
+package paramstore
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+// WatchOne polls the single parameter name with GetParameter every
+// WatchInterval and calls cb with its value whenever its version changes,
+// which is far cheaper than watching a whole path. The value is passed as
+// stored, decrypted according to WithDecryption and trimmed if TrimSpace is
+// set, without any transformer. The parameter is fetched once up front, so a
+// missing parameter fails WatchOne itself. Errors, WatchFetchTimeout,
+// WatchCallbackTimeout, MaxConsecutiveWatchErrors and WatchEmitInitial are
+// handled like in WatchContext, and the watch stops once ctx is done.
+func (ps *ParamStore) WatchOne(ctx context.Context, name string, cb func(value string, err error)) error {
+	cfg, client, err := ps.snapshot()
+
+	if err != nil {
+		return err
+	}
+
+	param, err := getOne(ctx, cfg, client, name)
+
+	if err != nil {
+		return err
+	}
+
+	// Adapt cb to the watch helpers
+	report := func(event interface{}, err error) {
+		value, _ := event.(string)
+
+		cb(value, err)
+	}
+
+	go func() {
+		// Report the current value so callers know the watch is live
+		if cfg.WatchEmitInitial {
+			notify(cfg, report, rawValue(cfg, param), nil)
+		}
+
+		ticker := time.NewTicker(cfg.WatchInterval)
+		defer ticker.Stop()
+
+		var streak errorStreak
+
+		version := param.Version
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			ps.mu.RLock()
+			cfg := ps.config
+			client := ps.client
+			ps.mu.RUnlock()
+
+			tickCtx, cancel := tickContext(ctx, cfg)
+
+			current, err := getOne(tickCtx, cfg, client, name)
+
+			cancel()
+
+			if err != nil {
+				// Stop quietly once the watch is cancelled
+				if ctx.Err() != nil || watchFailed(cfg, report, &streak, err) {
+					return
+				}
+
+				continue
+			}
+
+			watchSucceeded(cfg, &streak)
+
+			if current.Version == version {
+				continue
+			}
+
+			version = current.Version
+
+			notify(cfg, report, rawValue(cfg, current), nil)
+		}
+	}()
+
+	return nil
+}
+
+// getOne fetches a single parameter, waiting for the rate limit first.
+func getOne(ctx context.Context, cfg Config, client SSMClient, name string) (types.Parameter, error) {
+	if cfg.RateLimiter != nil {
+		if err := cfg.RateLimiter.Wait(ctx); err != nil {
+			return types.Parameter{}, err
+		}
+	}
+
+	result, err := client.GetParameter(ctx, &ssm.GetParameterInput{
+		Name:           aws.String(name),
+		WithDecryption: aws.Bool(cfg.WithDecryption),
+	})
+
+	if err != nil {
+		return types.Parameter{}, wrapClockSkew(err)
+	}
+
+	return *result.Parameter, nil
+}
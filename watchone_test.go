@@ -0,0 +1,71 @@
+package paramstore
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+func TestWatchOne(t *testing.T) {
+	fake := newFakeSSM("/app/flag", "off", "/app/other", "1")
+	ps := ProviderWithClient(Config{Path: "/app", WatchInterval: 5 * time.Millisecond}, nil, fake)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var mu sync.Mutex
+	var values []string
+
+	err := ps.WatchOne(ctx, "/app/flag", func(value string, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if err != nil {
+			t.Errorf("got error %v", err)
+		}
+
+		values = append(values, value)
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Other parameters don't matter
+	fake.set("/app/other", "2", types.ParameterTypeString)
+	time.Sleep(20 * time.Millisecond)
+
+	fake.set("/app/flag", "on", types.ParameterTypeString)
+
+	waitFor(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return len(values) > 0
+	})
+
+	// Polling the same version again reports nothing
+	time.Sleep(20 * time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if len(values) != 1 || values[0] != "on" {
+		t.Fatalf("got %q, want the new value once", values)
+	}
+
+	if fake.count("GetParametersByPath") != 0 {
+		t.Fatal("watched the whole path")
+	}
+}
+
+func TestWatchOneMissing(t *testing.T) {
+	ps := ProviderWithClient(Config{Path: "/app"}, nil, newFakeSSM())
+
+	if err := ps.WatchOne(context.Background(), "/app/flag", func(string, error) {}); err == nil {
+		t.Fatal("watched a missing parameter")
+	}
+}
@@ -0,0 +1,252 @@
+package paramstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+const (
+	// standardTierMaxSize is the largest value the Standard tier accepts
+	standardTierMaxSize = 4 * 1024
+	// advancedTierMaxSize is the largest value the Advanced tier accepts
+	advancedTierMaxSize = 8 * 1024
+)
+
+// WriteOptions controls how WriteParameter stores a value.
+type WriteOptions struct {
+	// Type defaults to types.ParameterTypeString.
+	Type        types.ParameterType
+	Description string
+	// KeyID is the KMS key of a SecureString, defaulting to
+	// Config.DefaultKMSKeyID and then to the account's AWS managed key.
+	KeyID     string
+	Overwrite bool
+	// Tier is selected from the value size when empty: values over 4KB use
+	// the Advanced tier, everything else Standard. IntelligentTiering is
+	// passed through and left for SSM to decide.
+	Tier types.ParameterTier
+	// SkipUnchanged reads the current value first and skips the write if
+	// both value and type already match, so no new version is created.
+	// Changes to only the description, key or tier are skipped as well.
+	SkipUnchanged bool
+	// OnWrite is called after every successful write with written set, and
+	// for every write skipped by SkipUnchanged without it.
+	OnWrite func(name string, written bool)
+}
+
+// WriteParameter stores value under name via PutParameter. With
+// UsePathPrefixOnWrite, name is relative to Path.
+func (ps *ParamStore) WriteParameter(ctx context.Context, name, value string, opts WriteOptions) error {
+	cfg, client, err := ps.snapshot()
+
+	if err != nil {
+		return err
+	}
+
+	input, err := putParameterInput(writeName(cfg, name), value, writeOptions(cfg, opts))
+
+	if err != nil {
+		return err
+	}
+
+	return put(ctx, client, input, opts)
+}
+
+// put stores input, skipping unchanged values if opts.SkipUnchanged is set,
+// and reports the outcome to opts.OnWrite.
+func put(ctx context.Context, client SSMClient, input *ssm.PutParameterInput, opts WriteOptions) error {
+	name := aws.ToString(input.Name)
+
+	if opts.SkipUnchanged {
+		unchanged, err := isUnchanged(ctx, client, input)
+
+		if err != nil {
+			return err
+		}
+
+		if unchanged {
+			if opts.OnWrite != nil {
+				opts.OnWrite(name, false)
+			}
+
+			return nil
+		}
+	}
+
+	if _, err := client.PutParameter(ctx, input); err != nil {
+		return err
+	}
+
+	if opts.OnWrite != nil {
+		opts.OnWrite(name, true)
+	}
+
+	return nil
+}
+
+// isUnchanged reports whether the stored parameter already has the value
+// and type of input.
+func isUnchanged(ctx context.Context, client SSMClient, input *ssm.PutParameterInput) (bool, error) {
+	result, err := client.GetParameter(ctx, &ssm.GetParameterInput{
+		Name:           input.Name,
+		WithDecryption: aws.Bool(true),
+	})
+
+	var notFound *types.ParameterNotFound
+
+	if errors.As(err, &notFound) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, fmt.Errorf("reading current value of %s: %w", aws.ToString(input.Name), err)
+	}
+
+	current := result.Parameter
+
+	return aws.ToString(current.Value) == aws.ToString(input.Value) && current.Type == input.Type, nil
+}
+
+// writeName resolves the SSM name for a write, prepending Path when
+// UsePathPrefixOnWrite is set.
+func writeName(cfg Config, name string) string {
+	if !cfg.UsePathPrefixOnWrite || cfg.Path == "" || name == "" {
+		return name
+	}
+
+	return strings.TrimSuffix(cfg.Path, "/") + "/" + strings.TrimPrefix(name, "/")
+}
+
+// writeOptions fills in the provider defaults for a SecureString write.
+func writeOptions(cfg Config, opts WriteOptions) WriteOptions {
+	if opts.Type == types.ParameterTypeSecureString && opts.KeyID == "" {
+		opts.KeyID = cfg.DefaultKMSKeyID
+	}
+
+	return opts
+}
+
+// putParameterInput builds the PutParameter input for a single write and
+// resolves the tier.
+func putParameterInput(name, value string, opts WriteOptions) (*ssm.PutParameterInput, error) {
+	if name == "" {
+		return nil, errors.New("no parameter name provided")
+	}
+
+	if len(value) > advancedTierMaxSize {
+		return nil, fmt.Errorf("value of %s is %d bytes, exceeding the %d byte SSM limit", name, len(value), advancedTierMaxSize)
+	}
+
+	tier := opts.Tier
+
+	switch tier {
+	case "":
+		tier = types.ParameterTierStandard
+
+		if len(value) > standardTierMaxSize {
+			tier = types.ParameterTierAdvanced
+		}
+	case types.ParameterTierStandard:
+		if len(value) > standardTierMaxSize {
+			return nil, fmt.Errorf("value of %s is %d bytes, the Standard tier allows at most %d", name, len(value), standardTierMaxSize)
+		}
+	case types.ParameterTierAdvanced, types.ParameterTierIntelligentTiering:
+	default:
+		return nil, fmt.Errorf("unknown parameter tier %q", tier)
+	}
+
+	typ := opts.Type
+
+	if typ == "" {
+		typ = types.ParameterTypeString
+	}
+
+	input := &ssm.PutParameterInput{
+		Name:      aws.String(name),
+		Value:     aws.String(value),
+		Type:      typ,
+		Tier:      tier,
+		Overwrite: aws.Bool(opts.Overwrite),
+	}
+
+	if opts.Description != "" {
+		input.Description = aws.String(opts.Description)
+	}
+
+	if opts.KeyID != "" {
+		input.KeyId = aws.String(opts.KeyID)
+	}
+
+	return input, nil
+}
+
+// Import writes every leaf of a local config file to SSM below Path. The file
+// is parsed by extension like in CompareWithFile, and its delimiter-joined
+// keys are turned into SSM names by replacing the delimiter with "/". Values
+// are stored as strings, lists comma-joined and numbers without exponents.
+// Null values can't be stored, so nothing is written if the file has any.
+// Redacted values from Export are skipped. All leaves are attempted; the
+// errors of failed writes are joined together.
+func (ps *ParamStore) Import(ctx context.Context, path string, opts WriteOptions) error {
+	cfg, client, err := ps.snapshot()
+
+	if err != nil {
+		return err
+	}
+
+	if cfg.Path == "" {
+		return errors.New("no parameter path provided")
+	}
+
+	k, err := loadFile(path, cfg.Delimiter)
+
+	if err != nil {
+		return err
+	}
+
+	values, err := stringifyValues(k.All())
+
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	keys := make([]string, 0, len(values))
+
+	for key := range values {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	var errs []error
+
+	for _, key := range keys {
+		name := strings.TrimSuffix(cfg.Path, "/") + "/" + strings.ReplaceAll(key, cfg.Delimiter, "/")
+
+		// Never overwrite a secret with the placeholder of a redacted export
+		if values[key] == redacted {
+			logf(cfg.Logger, "paramstore: not importing redacted value of %s", name)
+
+			continue
+		}
+
+		input, err := putParameterInput(name, values[key].(string), writeOptions(cfg, opts))
+
+		if err == nil {
+			err = put(ctx, client, input, opts)
+		}
+
+		if err != nil {
+			errs = append(errs, fmt.Errorf("writing %s: %w", name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
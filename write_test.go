@@ -0,0 +1,121 @@
+package paramstore
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+func TestWriteParameterTier(t *testing.T) {
+	for name, tc := range map[string]struct {
+		size int
+		tier types.ParameterTier
+		want types.ParameterTier
+	}{
+		"small":       {size: 100, want: types.ParameterTierStandard},
+		"large":       {size: 5 * 1024, want: types.ParameterTierAdvanced},
+		"intelligent": {size: 5 * 1024, tier: types.ParameterTierIntelligentTiering, want: types.ParameterTierIntelligentTiering},
+	} {
+		t.Run(name, func(t *testing.T) {
+			fake := newFakeSSM()
+			ps := ProviderWithClient(Config{Path: "/app"}, nil, fake)
+
+			if err := ps.WriteParameter(context.Background(), "/app/blob", strings.Repeat("x", tc.size), WriteOptions{Tier: tc.tier}); err != nil {
+				t.Fatal(err)
+			}
+
+			if len(fake.puts) != 1 || fake.puts[0].Tier != tc.want {
+				t.Fatalf("got %+v, want tier %s", fake.puts, tc.want)
+			}
+		})
+	}
+}
+
+func TestWriteParameterTierTooSmall(t *testing.T) {
+	fake := newFakeSSM()
+	ps := ProviderWithClient(Config{Path: "/app"}, nil, fake)
+
+	err := ps.WriteParameter(context.Background(), "/app/blob", strings.Repeat("x", 5*1024), WriteOptions{Tier: types.ParameterTierStandard})
+
+	if err == nil || fake.count("PutParameter") != 0 {
+		t.Fatalf("got %v after %d writes, want the Standard tier rejected", err, fake.count("PutParameter"))
+	}
+}
+
+func TestDefaultKMSKeyID(t *testing.T) {
+	fake := newFakeSSM()
+	ps := ProviderWithClient(Config{Path: "/app", DefaultKMSKeyID: "alias/app"}, nil, fake)
+	ctx := context.Background()
+
+	writes := []struct {
+		name string
+		opts WriteOptions
+		want string
+	}{
+		{"/app/secret", WriteOptions{Type: types.ParameterTypeSecureString}, "alias/app"},
+		{"/app/other", WriteOptions{Type: types.ParameterTypeSecureString, KeyID: "alias/other"}, "alias/other"},
+		{"/app/plain", WriteOptions{}, ""},
+	}
+
+	for _, w := range writes {
+		if err := ps.WriteParameter(ctx, w.name, "v", w.opts); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for i, w := range writes {
+		if got := aws.ToString(fake.puts[i].KeyId); got != w.want {
+			t.Errorf("%s written with key %q, want %q", w.name, got, w.want)
+		}
+	}
+}
+
+func TestImportFormatsValues(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+
+	if err := os.WriteFile(path, []byte(`{"n": 1000000, "f": 0.25, "l": [1, 2500000], "s": "x"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	fake := newFakeSSM()
+	ps := ProviderWithClient(Config{Path: "/app", Delimiter: "."}, nil, fake)
+
+	if err := ps.Import(context.Background(), path, WriteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	got := make(map[string]string)
+
+	for _, put := range fake.puts {
+		got[aws.ToString(put.Name)] = aws.ToString(put.Value)
+	}
+
+	want := map[string]string{"/app/n": "1000000", "/app/f": "0.25", "/app/l": "1,2500000", "/app/s": "x"}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestImportRejectsNull(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+
+	if err := os.WriteFile(path, []byte("db:\n  host: h\n  port: ~\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	fake := newFakeSSM()
+	ps := ProviderWithClient(Config{Path: "/app", Delimiter: "."}, nil, fake)
+
+	err := ps.Import(context.Background(), path, WriteOptions{})
+
+	if err == nil || !strings.Contains(err.Error(), "db.port") || fake.count("PutParameter") != 0 {
+		t.Fatalf("got %v after %d writes, want db.port rejected", err, fake.count("PutParameter"))
+	}
+}